@@ -0,0 +1,420 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package application
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	app "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+const (
+	helm_release_kind      = "HelmRelease"
+	part_of_label          = "app.kubernetes.io/part-of"
+	helm_release_name      = "meta.helm.sh/release-name"
+	helm_release_namespace = "meta.helm.sh/release-namespace"
+)
+
+// Root is where the walk of an application starts: a workload, a Helm release or an app.kubernetes.io/part-of value
+type Root struct {
+	Kind      string // workload kind or HelmRelease, empty for a part-of value
+	Namespace string
+	Name      string // name of the workload or release, or the part-of value
+}
+
+type Roots []Root
+
+func (r Root) String() string {
+	if r.Kind == "" {
+		return part_of_label + "=" + r.Name
+	}
+	return r.Kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// Parse a comma separated list of Kind/namespace/name, HelmRelease/namespace/release and part-of=value roots
+func Parse(value string) (Roots, error) {
+	var roots Roots
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "part-of=") {
+			roots = append(roots, Root{Name: strings.TrimPrefix(entry, "part-of=")})
+			continue
+		}
+		parts := strings.Split(entry, "/")
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid application %q, expected Kind/namespace/name, HelmRelease/namespace/release or part-of=value", entry)
+		}
+		if parts[0] != helm_release_kind && !is_workload(parts[0]) {
+			return nil, fmt.Errorf("invalid application %q, %s is not a workload kind", entry, parts[0])
+		}
+		roots = append(roots, Root{Kind: parts[0], Namespace: parts[1], Name: parts[2]})
+	}
+	return roots, nil
+}
+
+func is_workload(kind string) bool {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "CronJob", "Job":
+		return true
+	}
+	return false
+}
+
+// walk collects the objects reachable from the roots
+type walk struct {
+	resources *resource.Resources
+	objects   map[string]resource.Object // kind/namespace/name -> object
+	selected  map[string]bool
+	queue     []string
+}
+
+func key(kind string, namespace string, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// Select keeps only the objects reachable from the roots: the service accounts, secrets, config maps and claims of
+// the workloads, the services selecting their pods, the ingresses routing to those services, their autoscalers and
+// the roles bound to their service accounts
+func Select(resources *resource.Resources, roots Roots) {
+	if len(roots) == 0 {
+		return
+	}
+	fmt.Println("Application....start")
+
+	w := walk{resources: resources, objects: make(map[string]resource.Object), selected: make(map[string]bool)}
+	resources.Each(func(kind string, obj resource.Object) {
+		w.objects[key(kind, obj.GetNamespace(), obj.GetName())] = obj
+	})
+
+	releases := make(map[string]bool)
+	found := false
+	for _, root := range roots {
+		before := len(w.selected)
+		matched := false
+		switch root.Kind {
+		case "":
+			for k, obj := range w.objects {
+				if obj.GetLabels()[part_of_label] == root.Name {
+					w.select_key(k)
+				}
+			}
+		case helm_release_kind:
+			releases[root.Namespace+"/"+root.Name] = true
+			for k, obj := range w.objects {
+				annotations := obj.GetAnnotations()
+				if annotations[helm_release_name] == root.Name && annotations[helm_release_namespace] == root.Namespace {
+					w.select_key(k)
+				}
+			}
+			_, matched = resources.HelmList[root.Namespace][root.Name]
+		default:
+			w.reference(root.Kind, root.Namespace, root.Name, "the application")
+		}
+		matched = matched || len(w.selected) > before
+		if !matched {
+			fmt.Println("Application", root, "not found in the source cluster")
+		}
+		found = found || matched
+	}
+	if !found {
+		fmt.Println("None of the applications were found in the source cluster, exiting")
+		os.Exit(1)
+	}
+
+	for len(w.queue) > 0 {
+		k := w.queue[0]
+		w.queue = w.queue[1:]
+		w.follow(k, w.objects[k])
+	}
+
+	resources.Filter(func(kind string, obj resource.Object) bool {
+		return w.selected[key(kind, obj.GetNamespace(), obj.GetName())]
+	})
+	for namespace, charts := range resources.HelmList {
+		for release := range charts {
+			if !releases[namespace+"/"+release] {
+				delete(charts, release)
+			}
+		}
+	}
+
+	fmt.Printf("Application closure holds %d objects\n", len(w.selected))
+	fmt.Println("Application....End")
+}
+
+func (w *walk) select_key(k string) {
+	if w.selected[k] {
+		return
+	}
+	w.selected[k] = true
+	w.queue = append(w.queue, k)
+}
+
+// reference selects a referenced object, a missing one is reported because the application will not work without it
+func (w *walk) reference(kind string, namespace string, name string, from string) {
+	if name == "" {
+		return
+	}
+	k := key(kind, namespace, name)
+	if _, ok := w.objects[k]; !ok {
+		w.resources.Report.Add("application", kind, namespace, name, "referenced by "+from+" but not among the scanned objects")
+		return
+	}
+	w.select_key(k)
+}
+
+// optional selects an object that is fine to be missing, like the namespace or the default service account
+func (w *walk) optional(kind string, namespace string, name string) {
+	if k := key(kind, namespace, name); w.objects[k] != nil {
+		w.select_key(k)
+	}
+}
+
+func (w *walk) follow(k string, obj resource.Object) {
+	kind := strings.SplitN(k, "/", 2)[0]
+	namespace := obj.GetNamespace()
+	from := kind + " " + namespace + "/" + obj.GetName()
+	if namespace != "" {
+		w.optional("Namespace", "", namespace)
+	}
+
+	if template := resource.Pod_template(obj); template != nil {
+		w.pod_spec(&template.Spec, namespace, from)
+		w.services_selecting(template.ObjectMeta.Labels, namespace)
+		w.autoscalers_of(kind, namespace, obj.GetName())
+	}
+
+	switch o := obj.(type) {
+	case *app.StatefulSet:
+		w.reference("Service", namespace, o.Spec.ServiceName, from)
+		// claims created from the templates are named <template>-<statefulset>-<ordinal>
+		for _, template := range o.Spec.VolumeClaimTemplates {
+			prefix := template.ObjectMeta.Name + "-" + o.ObjectMeta.Name + "-"
+			for i := range w.resources.PersistentVolumeClaimsList {
+				pvc := &w.resources.PersistentVolumeClaimsList[i]
+				if pvc.ObjectMeta.Namespace == namespace && strings.HasPrefix(pvc.ObjectMeta.Name, prefix) && is_ordinal(strings.TrimPrefix(pvc.ObjectMeta.Name, prefix)) {
+					w.select_key(key("PersistentVolumeClaim", namespace, pvc.ObjectMeta.Name))
+				}
+			}
+			if template.Spec.StorageClassName != nil {
+				w.optional("StorageClass", "", *template.Spec.StorageClassName)
+			}
+		}
+	case *v1.ServiceAccount:
+		for _, secret := range o.Secrets {
+			w.optional("Secret", namespace, secret.Name)
+		}
+		for _, secret := range o.ImagePullSecrets {
+			w.reference("Secret", namespace, secret.Name, from)
+		}
+		w.bindings_of(namespace, o.ObjectMeta.Name)
+	case *v1.Service:
+		w.ingresses_routing_to(namespace, o.ObjectMeta.Name)
+	case *v1.PersistentVolumeClaim:
+		if o.Spec.StorageClassName != nil {
+			w.optional("StorageClass", "", *o.Spec.StorageClassName)
+		}
+	case *networking.Ingress:
+		for _, name := range ingress_services(o) {
+			w.reference("Service", namespace, name, from)
+		}
+		for _, tls := range o.Spec.TLS {
+			w.reference("Secret", namespace, tls.SecretName, from)
+		}
+	case *autoscaling.HorizontalPodAutoscaler:
+		w.reference(o.Spec.ScaleTargetRef.Kind, namespace, o.Spec.ScaleTargetRef.Name, from)
+	case *rbac.RoleBinding:
+		if o.RoleRef.Kind == "Role" {
+			w.reference("Role", namespace, o.RoleRef.Name, from)
+		} else {
+			w.optional("ClusterRole", "", o.RoleRef.Name)
+		}
+	case *rbac.ClusterRoleBinding:
+		w.optional("ClusterRole", "", o.RoleRef.Name)
+	}
+}
+
+// Service account, secrets, config maps and claims used by the pods
+func (w *walk) pod_spec(spec *v1.PodSpec, namespace string, from string) {
+	if spec.ServiceAccountName == "" || spec.ServiceAccountName == "default" {
+		w.optional("ServiceAccount", namespace, "default")
+	} else {
+		w.reference("ServiceAccount", namespace, spec.ServiceAccountName, from)
+	}
+	for _, secret := range spec.ImagePullSecrets {
+		w.reference("Secret", namespace, secret.Name, from)
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil && !optional(volume.ConfigMap.Optional) {
+			w.reference("ConfigMap", namespace, volume.ConfigMap.Name, from)
+		}
+		if volume.Secret != nil && !optional(volume.Secret.Optional) {
+			w.reference("Secret", namespace, volume.Secret.SecretName, from)
+		}
+		if volume.PersistentVolumeClaim != nil {
+			w.reference("PersistentVolumeClaim", namespace, volume.PersistentVolumeClaim.ClaimName, from)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil && !optional(source.ConfigMap.Optional) {
+					w.reference("ConfigMap", namespace, source.ConfigMap.Name, from)
+				}
+				if source.Secret != nil && !optional(source.Secret.Optional) {
+					w.reference("Secret", namespace, source.Secret.Name, from)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, from_source := range container.EnvFrom {
+			if from_source.ConfigMapRef != nil && !optional(from_source.ConfigMapRef.Optional) {
+				w.reference("ConfigMap", namespace, from_source.ConfigMapRef.Name, from)
+			}
+			if from_source.SecretRef != nil && !optional(from_source.SecretRef.Optional) {
+				w.reference("Secret", namespace, from_source.SecretRef.Name, from)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && !optional(ref.Optional) {
+				w.reference("ConfigMap", namespace, ref.Name, from)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil && !optional(ref.Optional) {
+				w.reference("Secret", namespace, ref.Name, from)
+			}
+		}
+	}
+}
+
+func optional(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+func (w *walk) services_selecting(pod_labels map[string]string, namespace string) {
+	for i := range w.resources.Svcl {
+		svc := &w.resources.Svcl[i]
+		if svc.ObjectMeta.Namespace != namespace || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod_labels)) {
+			w.select_key(key("Service", namespace, svc.ObjectMeta.Name))
+		}
+	}
+}
+
+func (w *walk) autoscalers_of(kind string, namespace string, name string) {
+	for i := range w.resources.HpaList {
+		hpa := &w.resources.HpaList[i]
+		target := hpa.Spec.ScaleTargetRef
+		if hpa.ObjectMeta.Namespace == namespace && target.Kind == kind && target.Name == name {
+			w.select_key(key("HorizontalPodAutoscaler", namespace, hpa.ObjectMeta.Name))
+		}
+	}
+}
+
+func (w *walk) ingresses_routing_to(namespace string, service string) {
+	for i := range w.resources.IngressList {
+		ingress := &w.resources.IngressList[i]
+		if ingress.ObjectMeta.Namespace != namespace {
+			continue
+		}
+		for _, name := range ingress_services(ingress) {
+			if name == service {
+				w.select_key(key("Ingress", namespace, ingress.ObjectMeta.Name))
+				break
+			}
+		}
+	}
+}
+
+func ingress_services(ingress *networking.Ingress) []string {
+	var names []string
+	if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		names = append(names, backend.Service.Name)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				names = append(names, path.Backend.Service.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Role bindings and cluster role bindings granting permissions to the service account
+func (w *walk) bindings_of(namespace string, service_account string) {
+	bound := func(subjects []rbac.Subject, binding_namespace string) bool {
+		for _, subject := range subjects {
+			subject_namespace := subject.Namespace
+			if subject_namespace == "" {
+				subject_namespace = binding_namespace
+			}
+			if subject.Kind == "ServiceAccount" && subject.Name == service_account && subject_namespace == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range w.resources.RoleBindingList {
+		rb := &w.resources.RoleBindingList[i]
+		if rb.ObjectMeta.Namespace == namespace && bound(rb.Subjects, rb.ObjectMeta.Namespace) {
+			w.select_key(key("RoleBinding", namespace, rb.ObjectMeta.Name))
+		}
+	}
+	for i := range w.resources.ClusterRoleBindingList {
+		crb := &w.resources.ClusterRoleBindingList[i]
+		if bound(crb.Subjects, "") {
+			w.select_key(key("ClusterRoleBinding", "", crb.ObjectMeta.Name))
+		}
+	}
+}
+
+// Tells whether value is the ordinal of a StatefulSet pod, the claims of a StatefulSet named <statefulset>-<suffix> are not
+func is_ordinal(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
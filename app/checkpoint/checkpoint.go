@@ -0,0 +1,325 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Progress of an object, an object only moves forward unless it fails
+const (
+	Scanned       = "scanned"
+	Images_copied = "images-copied"
+	Created       = "created"
+	Verified      = "verified"
+	Data_copied   = "data-copied"
+	Deleted       = "deleted"
+	Failed        = "failed"
+)
+
+var progress = map[string]int{Failed: 0, Scanned: 1, Images_copied: 2, Created: 3, Verified: 4, Data_copied: 5, Deleted: 6}
+
+// Seconds between two saves while a stage runs, the file is always saved at the end of a stage
+const save_interval = 5 * time.Second
+
+// Object is the progress of one object of the run, under its destination namespace and name
+type Object struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"` // last error when the status is failed
+	Updated   string `json:"updated"`
+}
+
+// Checkpoint is the state of a migration run, kept on disk under the run ID so an interrupted or failed run
+// can be resumed. The methods do nothing on a nil checkpoint so the clusters do not need one.
+type Checkpoint struct {
+	Run_id     string             `json:"runId"`
+	Action     string             `json:"action"`
+	Stage      string             `json:"stage"`            // stage running when the checkpoint was written: scan, deploy, delete or data
+	Reason     string             `json:"reason,omitempty"` // why the run stopped
+	Started    string             `json:"started"`
+	Written    string             `json:"written,omitempty"`
+	Namespaces []string           `json:"namespaces,omitempty"` // namespaces whose objects were all handled
+	Images     map[string]string  `json:"images,omitempty"`     // source image to the ECR image it was copied to, empty when it is not copied
+	Releases   map[string]int     `json:"releases,omitempty"`   // namespace/name of a Helm release to its revision before the run, 0 when the run installed it
+	Objects    map[string]*Object `json:"objects,omitempty"`    // by kind/namespace/name
+
+	path  string
+	saved time.Time
+	lock  sync.Mutex
+}
+
+// New checkpoint of a run, written to path
+func New(path string, run_id string, action string) *Checkpoint {
+	return &Checkpoint{Run_id: run_id, Action: action, Started: now(), Images: make(map[string]string), Releases: make(map[string]int), Objects: make(map[string]*Object), path: path}
+}
+
+// Load the checkpoint of an earlier run to resume it
+func Load(path string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if c.Images == nil {
+		c.Images = make(map[string]string)
+	}
+	if c.Releases == nil {
+		c.Releases = make(map[string]int)
+	}
+	if c.Objects == nil {
+		c.Objects = make(map[string]*Object)
+	}
+	c.path = path
+	c.Reason = ""
+	return &c, nil
+}
+
+// Path the checkpoint is written to
+func (c *Checkpoint) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.path
+}
+
+// Start records the stage the run enters
+func (c *Checkpoint) Start(stage string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Stage = stage
+}
+
+// Set moves an object to a status, an object never goes back to an earlier status but a failed object is retried
+func (c *Checkpoint) Set(kind string, namespace string, name string, status string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := kind + "/" + namespace + "/" + name
+	if obj, ok := c.Objects[key]; ok && obj.Status != Failed && progress[obj.Status] >= progress[status] {
+		return
+	}
+	c.Objects[key] = &Object{Kind: kind, Namespace: namespace, Name: name, Status: status, Updated: now()}
+}
+
+// Fail records the error of an object, it is retried when the run is resumed
+func (c *Checkpoint) Fail(kind string, namespace string, name string, err error) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := kind + "/" + namespace + "/" + name
+	c.Objects[key] = &Object{Kind: kind, Namespace: namespace, Name: name, Status: Failed, Error: err.Error(), Updated: now()}
+}
+
+// Reached tells whether an object already got to the status in this run or the run it resumes
+func (c *Checkpoint) Reached(kind string, namespace string, name string, status string) bool {
+	if c == nil {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	obj, ok := c.Objects[kind+"/"+namespace+"/"+name]
+	return ok && obj.Status != Failed && progress[obj.Status] >= progress[status]
+}
+
+// Image returns the image a source image was copied to, ok is false when the image was not handled yet
+func (c *Checkpoint) Image(source string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	image, ok := c.Images[source]
+	return image, ok
+}
+
+// Set_image records the image a source image was copied to, empty when the image stays where it is
+func (c *Checkpoint) Set_image(source string, image string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Images[source] = image
+}
+
+// Copied tells whether an image is the ECR image a source image was copied to
+func (c *Checkpoint) Copied(image string) bool {
+	if c == nil || image == "" {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, copied := range c.Images {
+		if copied == image {
+			return true
+		}
+	}
+	return false
+}
+
+// Release returns the revision a Helm release had before the run, 0 when the run installed it. ok is false when
+// the release was not handled yet.
+func (c *Checkpoint) Release(namespace string, name string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	revision, ok := c.Releases[namespace+"/"+name]
+	return revision, ok
+}
+
+// Set_release records the revision a Helm release had before the run installed or upgraded it
+func (c *Checkpoint) Set_release(namespace string, name string, revision int) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Releases[namespace+"/"+name] = revision
+}
+
+// Namespace_done records a namespace whose objects were all handled in the current stage
+func (c *Checkpoint) Namespace_done(namespace string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, done := range c.Namespaces {
+		if done == namespace {
+			return
+		}
+	}
+	c.Namespaces = append(c.Namespaces, namespace)
+}
+
+// Count the objects per status
+func (c *Checkpoint) Count() map[string]int {
+	counts := make(map[string]int)
+	if c == nil {
+		return counts
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, obj := range c.Objects {
+		counts[obj.Status]++
+	}
+	return counts
+}
+
+// Failures lists the objects that failed, sorted by key
+func (c *Checkpoint) Failures() []Object {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var keys []string
+	for key, obj := range c.Objects {
+		if obj.Status == Failed {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var failed []Object
+	for _, key := range keys {
+		failed = append(failed, *c.Objects[key])
+	}
+	return failed
+}
+
+// Save writes the checkpoint unless it was saved less than a few seconds ago, so it can be called after every namespace
+func (c *Checkpoint) Save() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	recent := time.Since(c.saved) < save_interval
+	c.lock.Unlock()
+	if recent {
+		return
+	}
+	c.Flush()
+}
+
+// Flush writes the checkpoint now, a failure to write it is printed but does not stop the migration
+func (c *Checkpoint) Flush() {
+	if err := c.Write(""); err != nil {
+		fmt.Printf("Could not save the checkpoint %s: %v\n", c.Path(), err)
+	}
+}
+
+// Write the checkpoint with the reason the run stopped, the file is replaced in one step so it is never half written
+func (c *Checkpoint) Write(reason string) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Reason = reason
+	c.Written = now()
+	c.saved = time.Now()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
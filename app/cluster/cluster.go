@@ -20,27 +20,274 @@
 package cluster
 
 import (
+	"context"
 	"os"
 	"fmt"
+	"time"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	application "containers-migration-factory/app/application"
+	checkpoint "containers-migration-factory/app/checkpoint"
+	ignore "containers-migration-factory/app/ignore"
+	scope "containers-migration-factory/app/scope"
+	transform "containers-migration-factory/app/transform"
 )
 
 // establish connection with ks8
 type Cluster struct {
 	Kubeconfig_path string                // Path to the kubeconfig file
-	Clientset       *kubernetes.Clientset // Client pointing the CKE cluster
+	Clientset       kubernetes.Interface  // Client pointing the CKE cluster
+	Dynamic         dynamic.Interface     // Client reading any kind of resource.Kinds by its group, version and resource
+	Rest_config     *rest.Config          // Client configuration the Clientset was created from
+	Qps             float32               // Client side queries per second towards the API server, client-go default when 0
+	Burst           int                   // Client side burst of queries towards the API server, client-go default when 0
+	Workers         int                   // Number of concurrent API calls when scanning or deploying
+	Page_size       int                   // Number of objects read per List call
+	Ctx             context.Context       // Cancelled when the migration is interrupted or runs out of time
+	Call_timeout    time.Duration         // Timeout of every API call, none when 0
+	Migration_timeout time.Duration       // Time the whole migration may take, none when 0
+	Checkpoint      *checkpoint.Checkpoint // What the run completed, written when it is interrupted
 	Region          string                // GCP region in which the cluster is running
 	Namespaces      []string              // namespaces in kubernetes cluster from which the resources will be scanned
+	Namespace_mapping map[string]string   // source namespace to destination namespace names
+	Run_id          string                // Identifier of the migration run
+	Labels          map[string]string     // Labels added to every migrated object
+	Annotations     map[string]string     // Annotations added to every migrated object
+	Name_prefix     string                // Prefix added to the name of every migrated object
+	Name_suffix     string                // Suffix added to the name of every migrated object
+	Rules           []transform.Rule      // Transformation rules applied to the scanned objects
+	Explain         bool                  // Print which transformation rules touched which objects
+	Storage_class_mapping string          // Path to the StorageClass mapping file, the built-in mapping is used when empty
 	Context         string                // context of Kubeconfig file
 	Resources       []string              // Resources to include
 	Helm_path       string                // Path to save helm path on local system
+	Helm_driver     string                // Helm storage driver used by the cluster (secret, configmap or sql)
+	Helm_package    string                // Package the extracted helm charts as .tgz archives
+	Helm_registry   string                // OCI registry the packaged helm charts are pushed to
+	Helm_plain_http string                // Use plain http when pushing to the OCI registry
 	Migrate_Images  string                // Migrate images from 3rd party registries to ECR
+	Migrate_data    string                // Copy the data of the PersistentVolumeClaims after deploy
+	Data_image      string                // Image of the pods transferring PersistentVolumeClaim data
+	Velero_backup   string                // Path to the Velero backup archive read by the VELERO source
+	Distribution    string                // Kubernetes distribution of a GENERIC source: kubeadm, openshift, rancher or eks
+	Ignore          ignore.List           // System components of the source platform that are not migrated
+	Scope           scope.Scope           // Selectors and name patterns narrowing what is read from the source cluster
+	Application     application.Roots     // Workloads, Helm releases or part-of values whose closure is migrated, everything when empty
+	Export_path     string                // Path of the Velero layout archive written by the Export action
+	Protected_namespaces []string         // Namespaces the Delete action never touches, besides the built-in ones
+	Assume_yes      bool                  // Delete without asking for confirmation
+	Delete_propagation string             // Propagation policy of the deletions: Background, Foreground or Orphan
+	Delete_wait     time.Duration         // Time to wait for the deleted objects to be gone, no wait when 0
     Registry_Names  []string              // List of 3rd party registry names
 
 }
 
+func (c *Cluster) SetCtx(ctx context.Context) {
+    c.Ctx = ctx
+}
+
+// GetCtx returns the context of the API calls, a context that is never cancelled when none was set
+func (c Cluster) GetCtx() context.Context {
+    if c.Ctx == nil {
+        return context.Background()
+    }
+    return c.Ctx
+}
+
+func (c *Cluster) SetCall_timeout(call_timeout time.Duration) {
+    c.Call_timeout = call_timeout
+}
+
+func (c Cluster) GetCall_timeout() time.Duration {
+    return c.Call_timeout
+}
+
+func (c *Cluster) SetMigration_timeout(migration_timeout time.Duration) {
+    c.Migration_timeout = migration_timeout
+}
+
+func (c Cluster) GetMigration_timeout() time.Duration {
+    return c.Migration_timeout
+}
+
+func (c *Cluster) SetCheckpoint(checkpoint *checkpoint.Checkpoint) {
+    c.Checkpoint = checkpoint
+}
+
+func (c Cluster) GetCheckpoint() *checkpoint.Checkpoint {
+    return c.Checkpoint
+}
+
+// Exit_if_interrupted writes the checkpoint and exits when the migration was interrupted or ran out of time
+func (c Cluster) Exit_if_interrupted() {
+    err := c.GetCtx().Err()
+    if err == nil {
+        return
+    }
+    reason := "interrupted"
+    if err == context.DeadlineExceeded {
+        reason = "migration timeout exceeded"
+    }
+    fmt.Println()
+    fmt.Println("Migration stopped:", reason)
+    if c.Checkpoint != nil {
+        if werr := c.Checkpoint.Write(reason); werr != nil {
+            fmt.Printf("Could not write the checkpoint %s: %v\n", c.Checkpoint.Path(), werr)
+        } else {
+            fmt.Println("Checkpoint of the completed work written to", c.Checkpoint.Path())
+            fmt.Println("Run again with --resume", c.Checkpoint.Run_id, "to continue where it stopped")
+        }
+    }
+    os.Exit(130)
+}
+
+func (c *Cluster) SetQps(qps float32) {
+    c.Qps = qps
+}
+
+func (c Cluster) GetQps() float32 {
+    return c.Qps
+}
+
+func (c *Cluster) SetBurst(burst int) {
+    c.Burst = burst
+}
+
+func (c Cluster) GetBurst() int {
+    return c.Burst
+}
+
+func (c *Cluster) SetWorkers(workers int) {
+    c.Workers = workers
+}
+
+func (c Cluster) GetWorkers() int {
+    return c.Workers
+}
+
+func (c *Cluster) SetPage_size(page_size int) {
+    c.Page_size = page_size
+}
+
+func (c Cluster) GetPage_size() int {
+    return c.Page_size
+}
+
+func (c *Cluster) SetDynamic(dynamic dynamic.Interface) {
+    c.Dynamic = dynamic
+}
+
+func (c Cluster) GetDynamic() dynamic.Interface {
+    return c.Dynamic
+}
+
+func (c *Cluster) SetRest_config(rest_config *rest.Config) {
+    c.Rest_config = rest_config
+}
+
+func (c Cluster) GetRest_config() *rest.Config {
+    return c.Rest_config
+}
+
+func (c *Cluster) SetMigrate_data(migrate_data string) {
+    c.Migrate_data = migrate_data
+}
+
+func (c Cluster) GetMigrate_data() string {
+    return c.Migrate_data
+}
+
+func (c *Cluster) SetData_image(data_image string) {
+    c.Data_image = data_image
+}
+
+func (c Cluster) GetData_image() string {
+    return c.Data_image
+}
+
+func (c *Cluster) SetVelero_backup(velero_backup string) {
+    c.Velero_backup = velero_backup
+}
+
+func (c Cluster) GetVelero_backup() string {
+    return c.Velero_backup
+}
+
+func (c *Cluster) SetExport_path(export_path string) {
+    c.Export_path = export_path
+}
+
+func (c Cluster) GetExport_path() string {
+    return c.Export_path
+}
+
+func (c *Cluster) SetProtected_namespaces(protected_namespaces []string) {
+    c.Protected_namespaces = protected_namespaces
+}
+
+func (c Cluster) GetProtected_namespaces() []string {
+    return c.Protected_namespaces
+}
+
+func (c *Cluster) SetAssume_yes(assume_yes bool) {
+    c.Assume_yes = assume_yes
+}
+
+func (c Cluster) GetAssume_yes() bool {
+    return c.Assume_yes
+}
+
+func (c *Cluster) SetDelete_propagation(delete_propagation string) {
+    c.Delete_propagation = delete_propagation
+}
+
+func (c Cluster) GetDelete_propagation() string {
+    return c.Delete_propagation
+}
+
+func (c *Cluster) SetDelete_wait(delete_wait time.Duration) {
+    c.Delete_wait = delete_wait
+}
+
+func (c Cluster) GetDelete_wait() time.Duration {
+    return c.Delete_wait
+}
+
+func (c *Cluster) SetApplication(roots application.Roots) {
+    c.Application = roots
+}
+
+func (c Cluster) GetApplication() application.Roots {
+    return c.Application
+}
+
+func (c *Cluster) SetScope(scope scope.Scope) {
+    c.Scope = scope
+}
+
+func (c Cluster) GetScope() scope.Scope {
+    return c.Scope
+}
+
+func (c *Cluster) SetIgnore(ignore ignore.List) {
+    c.Ignore = ignore
+}
+
+func (c Cluster) GetIgnore() ignore.List {
+    return c.Ignore
+}
+
+func (c *Cluster) SetDistribution(distribution string) {
+    c.Distribution = distribution
+}
+
+func (c Cluster) GetDistribution() string {
+    return c.Distribution
+}
+
 func (c *Cluster) SetKubeconfig_path(kubeconfig_path string) {
     c.Kubeconfig_path = kubeconfig_path
 }
@@ -49,11 +296,11 @@ func (c Cluster) GetKubeconfig_path() string {
     return c.Kubeconfig_path
 }
 
-func (c *Cluster) SetClientset(clientset *kubernetes.Clientset) {
+func (c *Cluster) SetClientset(clientset kubernetes.Interface) {
     c.Clientset = clientset
 }
 
-func (c Cluster) GetClientset() *kubernetes.Clientset {
+func (c Cluster) GetClientset() kubernetes.Interface {
     return c.Clientset
 }
 
@@ -73,6 +320,78 @@ func (c Cluster) GetNamespaces() []string {
     return c.Namespaces
 }
 
+func (c *Cluster) SetNamespace_mapping(namespace_mapping map[string]string) {
+    c.Namespace_mapping = namespace_mapping
+}
+
+func (c Cluster) GetNamespace_mapping() map[string]string {
+    return c.Namespace_mapping
+}
+
+func (c *Cluster) SetRun_id(run_id string) {
+    c.Run_id = run_id
+}
+
+func (c Cluster) GetRun_id() string {
+    return c.Run_id
+}
+
+func (c *Cluster) SetLabels(labels map[string]string) {
+    c.Labels = labels
+}
+
+func (c Cluster) GetLabels() map[string]string {
+    return c.Labels
+}
+
+func (c *Cluster) SetAnnotations(annotations map[string]string) {
+    c.Annotations = annotations
+}
+
+func (c Cluster) GetAnnotations() map[string]string {
+    return c.Annotations
+}
+
+func (c *Cluster) SetName_prefix(name_prefix string) {
+    c.Name_prefix = name_prefix
+}
+
+func (c Cluster) GetName_prefix() string {
+    return c.Name_prefix
+}
+
+func (c *Cluster) SetName_suffix(name_suffix string) {
+    c.Name_suffix = name_suffix
+}
+
+func (c Cluster) GetName_suffix() string {
+    return c.Name_suffix
+}
+
+func (c *Cluster) SetRules(rules []transform.Rule) {
+    c.Rules = rules
+}
+
+func (c Cluster) GetRules() []transform.Rule {
+    return c.Rules
+}
+
+func (c *Cluster) SetExplain(explain bool) {
+    c.Explain = explain
+}
+
+func (c Cluster) GetExplain() bool {
+    return c.Explain
+}
+
+func (c *Cluster) SetStorage_class_mapping(storage_class_mapping string) {
+    c.Storage_class_mapping = storage_class_mapping
+}
+
+func (c Cluster) GetStorage_class_mapping() string {
+    return c.Storage_class_mapping
+}
+
 func (c *Cluster) SetContext(context string) {
     c.Context = context
 }
@@ -97,6 +416,38 @@ func (c Cluster) GetHelm_path() string {
     return c.Helm_path
 }
 
+func (c *Cluster) SetHelm_driver(helm_driver string) {
+    c.Helm_driver = helm_driver
+}
+
+func (c Cluster) GetHelm_driver() string {
+    return c.Helm_driver
+}
+
+func (c *Cluster) SetHelm_package(helm_package string) {
+    c.Helm_package = helm_package
+}
+
+func (c Cluster) GetHelm_package() string {
+    return c.Helm_package
+}
+
+func (c *Cluster) SetHelm_registry(helm_registry string) {
+    c.Helm_registry = helm_registry
+}
+
+func (c Cluster) GetHelm_registry() string {
+    return c.Helm_registry
+}
+
+func (c *Cluster) SetHelm_plain_http(helm_plain_http string) {
+    c.Helm_plain_http = helm_plain_http
+}
+
+func (c Cluster) GetHelm_plain_http() string {
+    return c.Helm_plain_http
+}
+
 func (c *Cluster) SetMigrate_Images(migrate_images string) {
     c.Migrate_Images = migrate_images
 }
@@ -131,6 +482,26 @@ func (c *Cluster) Generate_cluster_client() {
 		fmt.Printf("The kubeconfig cannot be loaded: %v\n", err)
 		os.Exit(1)
 	}
+	if c.Qps > 0 {
+		config.QPS = c.Qps
+	}
+	if c.Burst > 0 {
+		config.Burst = c.Burst
+	}
+	if c.Call_timeout > 0 {
+		config.Timeout = c.Call_timeout
+	}
 	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Printf("The cluster client cannot be created: %v\n", err)
+		os.Exit(1)
+	}
+	dynamic_client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		fmt.Printf("The cluster client cannot be created: %v\n", err)
+		os.Exit(1)
+	}
+	c.SetRest_config ( config )
 	c.SetClientset ( clientset )
+	c.SetDynamic ( dynamic_client )
 }
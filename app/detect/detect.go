@@ -0,0 +1,162 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cluster "containers-migration-factory/app/cluster"
+)
+
+// Distributions of the GENERIC source type
+const (
+	Kubeadm   = "kubeadm"
+	OpenShift = "openshift"
+	Rancher   = "rancher"
+	EKS       = "eks"
+)
+
+// Result is the platform a cluster runs on
+type Result struct {
+	Source_type  string // GKE, AKS, KOPS or GENERIC
+	Distribution string // distribution of a GENERIC cluster, empty when unknown
+	Reason       string // what the detection was based on
+	Unknown      bool   // nothing identified the platform, GENERIC is only assumed
+}
+
+func (r Result) String() string {
+	if r.Distribution != "" {
+		return r.Source_type + " (" + r.Distribution + ")"
+	}
+	return r.Source_type
+}
+
+// Detect the platform of a cluster from the labels and providerID of its nodes and from its server version. When nothing
+// identifies the platform the result is an unknown GENERIC cluster.
+func Detect(c *cluster.Cluster) (Result, error) {
+	nodes, nodesErr := c.GetClientset().CoreV1().Nodes().List(c.GetCtx(), metav1.ListOptions{Limit: 20})
+	if nodesErr == nil {
+		if result, ok := from_node_labels(nodes.Items); ok {
+			return result, nil
+		}
+	}
+
+	result, serverErr := from_server(c)
+	if serverErr == nil && result.Source_type != "" {
+		return result, nil
+	}
+	if nodesErr != nil && serverErr != nil {
+		return Result{}, fmt.Errorf("listing nodes: %v, reading the server version: %v", nodesErr, serverErr)
+	}
+
+	// nodes without platform labels, fall back on the cloud provider
+	if nodesErr == nil {
+		for _, node := range nodes.Items {
+			providerID := node.Spec.ProviderID
+			switch {
+			case strings.HasPrefix(providerID, "gce://"):
+				return Result{"GENERIC", Kubeadm, "node " + node.ObjectMeta.Name + " runs on GCE outside of GKE", false}, nil
+			case strings.HasPrefix(providerID, "azure://"):
+				return Result{"GENERIC", Kubeadm, "node " + node.ObjectMeta.Name + " runs on Azure outside of AKS", false}, nil
+			case strings.HasPrefix(providerID, "aws://"):
+				return Result{"GENERIC", Kubeadm, "node " + node.ObjectMeta.Name + " runs on AWS outside of EKS and kops", false}, nil
+			}
+		}
+	}
+	if nodesErr != nil {
+		return Result{"GENERIC", Kubeadm, fmt.Sprintf("no platform specific server version found and the nodes cannot be listed: %v", nodesErr), true}, nil
+	}
+	return Result{"GENERIC", Kubeadm, "no platform specific labels or server version found", true}, nil
+}
+
+func from_node_labels(nodes []v1.Node) (Result, bool) {
+	for _, node := range nodes {
+		labels := node.ObjectMeta.Labels
+		providerID := node.Spec.ProviderID
+
+		switch {
+		case has_label(labels, "cloud.google.com/gke-nodepool"), strings.HasPrefix(providerID, "gce://") && has_label(labels, "cloud.google.com/gke-os-distribution"):
+			return Result{"GKE", "", "node " + node.ObjectMeta.Name + " belongs to a GKE node pool", false}, true
+		case has_label(labels, "kubernetes.azure.com/cluster"), has_label(labels, "kubernetes.azure.com/agentpool"):
+			return Result{"AKS", "", "node " + node.ObjectMeta.Name + " belongs to an AKS agent pool", false}, true
+		case has_label(labels, "kops.k8s.io/instancegroup"):
+			return Result{"KOPS", "", "node " + node.ObjectMeta.Name + " belongs to a kops instance group", false}, true
+		case has_label(labels, "eks.amazonaws.com/nodegroup"), has_label(labels, "alpha.eksctl.io/cluster-name"), has_label(labels, "eks.amazonaws.com/compute-type"):
+			return Result{"GENERIC", EKS, "node " + node.ObjectMeta.Name + " belongs to an EKS node group", false}, true
+		case has_label(labels, "node.openshift.io/os_id"):
+			return Result{"GENERIC", OpenShift, "node " + node.ObjectMeta.Name + " runs OpenShift", false}, true
+		case has_label(labels, "rke.cattle.io/machine"), has_label(labels, "cattle.io/os"), labels["node.kubernetes.io/instance-type"] == "k3s", labels["node.kubernetes.io/instance-type"] == "rke2":
+			return Result{"GENERIC", Rancher, "node " + node.ObjectMeta.Name + " is managed by Rancher", false}, true
+		}
+	}
+	return Result{}, false
+}
+
+// Managed platforms mark the server version, OpenShift serves its own API groups
+func from_server(c *cluster.Cluster) (Result, error) {
+	discovery := c.GetClientset().Discovery()
+	version, err := discovery.ServerVersion()
+	if err != nil {
+		return Result{}, err
+	}
+	gitVersion := version.GitVersion
+	switch {
+	case strings.Contains(gitVersion, "-gke."):
+		return Result{"GKE", "", "server version " + gitVersion, false}, nil
+	case strings.Contains(gitVersion, "-eks-"):
+		return Result{"GENERIC", EKS, "server version " + gitVersion, false}, nil
+	case strings.Contains(gitVersion, "+k3s"), strings.Contains(gitVersion, "+rke2"):
+		return Result{"GENERIC", Rancher, "server version " + gitVersion, false}, nil
+	}
+
+	groups, err := discovery.ServerGroups()
+	if err != nil {
+		return Result{}, err
+	}
+	for _, group := range groups.Groups {
+		if strings.HasSuffix(group.Name, ".openshift.io") {
+			return Result{"GENERIC", OpenShift, "server serves the " + group.Name + " API group", false}, nil
+		}
+	}
+	return Result{}, nil
+}
+
+// Check returns an explanation when the configured source type does not match the detected one, GENERIC matches every
+// cluster. conclusive is false when the platform of the cluster is unknown, the mismatch then only deserves a warning.
+func Check(sourceType string, detected Result) (mismatch string, conclusive bool) {
+	if sourceType == "AKE" {
+		sourceType = "AKS"
+	}
+	if sourceType == "GENERIC" || sourceType == "VELERO" || sourceType == detected.Source_type {
+		return "", true
+	}
+	if detected.Unknown {
+		return fmt.Sprintf("the source type %s cannot be confirmed, the platform of the source cluster is unknown: %s", sourceType, detected.Reason), false
+	}
+	return fmt.Sprintf("the source type is %s but the source cluster looks like %v: %s", sourceType, detected, detected.Reason), true
+}
+
+func has_label(labels map[string]string, key string) bool {
+	_, ok := labels[key]
+	return ok
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package ignore
+
+import (
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	yaml "github.com/ghodss/yaml"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+//go:embed system-components.yaml
+var default_system_components []byte
+
+// File is the content of a system components file
+type File struct {
+	Platforms     map[string]List `json:"platforms,omitempty"`     // lists per source type, common applies to all of them
+	Distributions map[string]List `json:"distributions,omitempty"` // lists per distribution of a GENERIC source
+}
+
+// List holds the system components that the destination cluster brings itself
+type List struct {
+	Namespaces []string          `json:"namespaces,omitempty"` // globs of namespaces whose objects are not migrated
+	Rules      []Rule            `json:"rules,omitempty"`      // objects to skip by kind and name
+	Labels     map[string]string `json:"labels,omitempty"`     // objects carrying one of these labels are skipped, an empty value matches any value
+}
+
+// Rule skips the objects of the listed kinds whose name matches the glob, all kinds when kinds is empty
+type Rule struct {
+	Kinds     []string `json:"kinds,omitempty"`
+	Namespace string   `json:"namespace,omitempty"` // glob of the namespace, any namespace when empty
+	Name      string   `json:"name"`
+}
+
+// Load the built-in system components, the lists of the user file replace the built-in lists of the same name
+func Load(file string) (*File, error) {
+	var lists File
+	if err := yaml.Unmarshal(default_system_components, &lists); err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return &lists, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var user File
+	if err := yaml.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	for name, list := range user.Platforms {
+		lists.Platforms[strings.ToLower(name)] = list
+	}
+	for name, list := range user.Distributions {
+		lists.Distributions[strings.ToLower(name)] = list
+	}
+
+	for _, list := range append(values(lists.Platforms), values(lists.Distributions)...) {
+		if err := list.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &lists, nil
+}
+
+// For merges the common list with the lists of the source type and the distribution
+func (f *File) For(sourceType string, distribution string) List {
+	var merged List
+	merged.add(f.Platforms["common"])
+	merged.add(f.Platforms[strings.ToLower(sourceType)])
+	if distribution != "" {
+		if list, ok := f.Distributions[strings.ToLower(distribution)]; ok {
+			merged.add(list)
+		} else {
+			fmt.Printf("No system components listed for distribution %s, only the common ones are skipped\n", distribution)
+		}
+	}
+	return merged
+}
+
+// Skip_namespace tells whether the objects of the namespace are system components
+func (l List) Skip_namespace(namespace string) bool {
+	for _, pattern := range l.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches tells whether the object is a system component
+func (l List) Matches(kind string, obj resource.Object) bool {
+	namespace := obj.GetNamespace()
+	if kind == "Namespace" {
+		namespace = obj.GetName()
+	}
+	if namespace != "" && l.Skip_namespace(namespace) {
+		return true
+	}
+	for _, rule := range l.Rules {
+		if len(rule.Kinds) > 0 && !contains(rule.Kinds, kind) {
+			continue
+		}
+		if rule.Namespace != "" {
+			if ok, _ := path.Match(rule.Namespace, obj.GetNamespace()); !ok {
+				continue
+			}
+		}
+		if ok, _ := path.Match(rule.Name, obj.GetName()); ok {
+			return true
+		}
+	}
+	// the built-in StorageClasses of GKE and AKS carry the add-on manager labels, the storage class mapping translates
+	// them to EBS and EFS classes so the claims using them keep a class on EKS
+	if kind == "StorageClass" {
+		return false
+	}
+	labels := obj.GetLabels()
+	for key, value := range l.Labels {
+		if current, ok := labels[key]; ok && (value == "" || value == current) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove_system_components drops the objects of every kind that the list matches
+func Remove_system_components(resources *resource.Resources, list List) {
+	removed := make(map[string]int)
+	resources.Filter(func(kind string, obj resource.Object) bool {
+		if !list.Matches(kind, obj) {
+			return true
+		}
+		removed[kind]++
+		resources.Report.Add("ignore", kind, obj.GetNamespace(), obj.GetName(), "system component, not migrated")
+		return false
+	})
+
+	if len(removed) > 0 {
+		var counts []string
+		for kind, count := range removed {
+			counts = append(counts, fmt.Sprintf("%s %d", kind, count))
+		}
+		sort.Strings(counts)
+		fmt.Println("Skipped system components:", strings.Join(counts, ", "))
+	}
+}
+
+func (l *List) add(other List) {
+	l.Namespaces = append(l.Namespaces, other.Namespaces...)
+	l.Rules = append(l.Rules, other.Rules...)
+	if len(other.Labels) > 0 && l.Labels == nil {
+		l.Labels = make(map[string]string)
+	}
+	for key, value := range other.Labels {
+		l.Labels[key] = value
+	}
+}
+
+func (l List) validate() error {
+	patterns := append([]string{}, l.Namespaces...)
+	for _, rule := range l.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("system component rule for kinds %v needs a name", rule.Kinds)
+		}
+		patterns = append(patterns, rule.Name, rule.Namespace)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid system component pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+func values(lists map[string]List) []List {
+	var all []List
+	for _, list := range lists {
+		all = append(all, list)
+	}
+	return all
+}
+
+func contains(list []string, item string) bool {
+	for _, element := range list {
+		if element == item {
+			return true
+		}
+	}
+	return false
+}
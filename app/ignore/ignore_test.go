@@ -0,0 +1,56 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package ignore
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+)
+
+func TestRemoveSystemComponentsKeepsPlatformStorageClasses(t *testing.T) {
+	lists, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addon := map[string]string{"addonmanager.kubernetes.io/mode": "EnsureExists"}
+	resources := resource.Resources{
+		Report: report.New(),
+		StorageClassList: []storage.StorageClass{
+			{ObjectMeta: metav1.ObjectMeta{Name: "standard-rwo", Labels: addon}, Provisioner: "pd.csi.storage.gke.io"},
+		},
+		ConfigMapsList: []v1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "addon", Labels: addon}},
+		},
+	}
+
+	Remove_system_components(&resources, lists.For("GKE", ""))
+
+	if len(resources.StorageClassList) != 1 {
+		t.Errorf("StorageClasses = %d, want the labelled GKE class kept", len(resources.StorageClassList))
+	}
+	if len(resources.ConfigMapsList) != 0 {
+		t.Errorf("ConfigMaps = %d, want the labelled ConfigMap skipped", len(resources.ConfigMapsList))
+	}
+}
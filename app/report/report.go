@@ -0,0 +1,70 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package report
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Entry is a single finding recorded while scanning, transforming or deploying
+type Entry struct {
+	Stage     string // stage of the migration that produced the entry, e.g. "helm"
+	Kind      string // Kubernetes kind of the object the entry refers to
+	Namespace string // namespace of the object, empty for cluster scoped objects
+	Name      string // name of the object
+	Message   string // human readable description of what happened
+}
+
+// Report collects the findings of a migration run so they can be shown to the user at the end
+type Report struct {
+	mu      sync.Mutex
+	Entries []Entry
+}
+
+func New() *Report {
+	return &Report{}
+}
+
+// Add records a finding in the report
+func (r *Report) Add(stage string, kind string, namespace string, name string, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Entries = append(r.Entries, Entry{Stage: stage, Kind: kind, Namespace: namespace, Name: name, Message: message})
+}
+
+// Print writes the collected findings to stdout
+func (r *Report) Print() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.Entries) == 0 {
+		return
+	}
+	fmt.Println("=====================================================================")
+	fmt.Println("Migration report")
+	fmt.Println("=====================================================================")
+	for _, entry := range r.Entries {
+		object := entry.Name
+		if entry.Namespace != "" {
+			object = entry.Namespace + "/" + entry.Name
+		}
+		fmt.Printf("[%s] %s %s: %s\n", entry.Stage, entry.Kind, object, entry.Message)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package resource
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Kind describes how a kind handled by KMF is served by the Kubernetes API
+type Kind struct {
+	Kind       string
+	Resource   string // plural resource name, e.g. deployments
+	Group      string // API group, empty for the core group
+	Version    string // version KMF reads and writes the kind with
+	Namespaced bool
+	Names      []string // values of RESOURCES selecting the kind besides all
+}
+
+// Kinds handled by KMF, in the order Each visits them
+var Kinds = []Kind{
+	{"Namespace", "namespaces", "", "v1", false, []string{"namespace", "namespaces", "ns"}},
+	{"Service", "services", "", "v1", true, []string{"service", "services", "svc"}},
+	{"DaemonSet", "daemonsets", "apps", "v1", true, []string{"daemonset", "daemonsets", "ds"}},
+	{"Secret", "secrets", "", "v1", true, []string{"secret", "secrets"}},
+	{"Deployment", "deployments", "apps", "v1", true, []string{"deployment", "deployments", "deploy"}},
+	{"StatefulSet", "statefulsets", "apps", "v1", true, []string{"statefulset", "statefulsets", "sts"}},
+	{"StorageClass", "storageclasses", "storage.k8s.io", "v1", false, []string{"storageclass", "storageclasses", "sc"}},
+	{"ConfigMap", "configmaps", "", "v1", true, []string{"configmap", "configmaps", "cm"}},
+	{"Ingress", "ingresses", "networking.k8s.io", "v1", true, []string{"ingress", "ingresses", "ing"}},
+	{"Role", "roles", "rbac.authorization.k8s.io", "v1", true, []string{"role", "roles"}},
+	{"RoleBinding", "rolebindings", "rbac.authorization.k8s.io", "v1", true, []string{"rolebinding", "rolebindings"}},
+	{"ClusterRole", "clusterroles", "rbac.authorization.k8s.io", "v1", false, []string{"clusterrole", "clusterroles"}},
+	{"ClusterRoleBinding", "clusterrolebindings", "rbac.authorization.k8s.io", "v1", false, []string{"clusterrolebinding", "clusterrolebindings"}},
+	{"HorizontalPodAutoscaler", "horizontalpodautoscalers", "autoscaling", "v1", true, []string{"horizontalpodautoscaler", "horizontalpodautoscalers", "hpa"}},
+	{"PodSecurityPolicy", "podsecuritypolicies", "policy", "v1beta1", false, []string{"podsecuritypolicy", "podsecuritypolicies", "psp"}},
+	{"ServiceAccount", "serviceaccounts", "", "v1", true, []string{"serviceaccount", "serviceaccounts", "sa"}},
+	{"CronJob", "cronjobs", "batch", "v1beta1", true, []string{"cronjob", "cronjobs", "cj"}},
+	{"Job", "jobs", "batch", "v1", true, []string{"job", "jobs"}},
+	{"PersistentVolumeClaim", "persistentvolumeclaims", "", "v1", true, []string{"persistentvolumeclaim", "persistentvolumeclaims", "pvc"}},
+	{"MutatingWebhookConfiguration", "mutatingwebhookconfigurations", "admissionregistration.k8s.io", "v1", false, []string{"mutatingwebhookconfiguration", "mutatingwebhookconfigurations"}},
+	{"ValidatingWebhookConfiguration", "validatingwebhookconfigurations", "admissionregistration.k8s.io", "v1", false, []string{"validatingwebhookconfiguration", "validatingwebhookconfigurations"}},
+}
+
+// Find_kind returns the description of a kind handled by KMF
+func Find_kind(kind string) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Kind == kind {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Selected tells whether RESOURCES selects the kind
+func (k Kind) Selected(resources []string) bool {
+	for _, r := range resources {
+		r = strings.ToLower(r)
+		if r == "all" {
+			return true
+		}
+		for _, name := range k.Names {
+			if r == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (k Kind) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: k.Group, Version: k.Version, Kind: k.Kind}
+}
+
+func (k Kind) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: k.Group, Version: k.Version, Resource: k.Resource}
+}
@@ -32,7 +32,11 @@ import (
 	admissionregistration "k8s.io/api/admissionregistration/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+
+	report "containers-migration-factory/app/report"
 )
 
 type Resources struct {
@@ -42,6 +46,7 @@ type Resources struct {
 	SecretList []v1.Secret
 	//var map[string]
 	Depl                               []app.Deployment
+	StatefulSetList                    []app.StatefulSet
 	StorageClassList                   []storage.StorageClass
 	ConfigMapsList                     []v1.ConfigMap
 	IngressList                        []networking.Ingress
@@ -58,5 +63,252 @@ type Resources struct {
 	MutatingWebhookConfigurationList   []admissionregistration.MutatingWebhookConfiguration
 	ValidatingWebhookConfigurationList []admissionregistration.ValidatingWebhookConfiguration
 	HelmList						    map[string]map[string]string // Helm data namespace: [ release name : path to chart]
+	Report                             *report.Report               // Findings collected during the migration run
 	//	crdList *unstructured.UnstructuredList //[]apiextensions.CustomResourceDefinition
-}
\ No newline at end of file
+}
+
+// Object is implemented by a pointer to any of the scanned objects
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Each calls fn with a pointer to every scanned object together with its kind, namespaces first.
+// Changes made through the pointer are kept in the resources.
+func (r *Resources) Each(fn func(kind string, obj Object)) {
+	if r.Nsl != nil {
+		for i := range r.Nsl.Items {
+			fn("Namespace", &r.Nsl.Items[i])
+		}
+	}
+	for i := range r.Svcl {
+		fn("Service", &r.Svcl[i])
+	}
+	for i := range r.Dsl {
+		fn("DaemonSet", &r.Dsl[i])
+	}
+	for i := range r.SecretList {
+		fn("Secret", &r.SecretList[i])
+	}
+	for i := range r.Depl {
+		fn("Deployment", &r.Depl[i])
+	}
+	for i := range r.StatefulSetList {
+		fn("StatefulSet", &r.StatefulSetList[i])
+	}
+	for i := range r.StorageClassList {
+		fn("StorageClass", &r.StorageClassList[i])
+	}
+	for i := range r.ConfigMapsList {
+		fn("ConfigMap", &r.ConfigMapsList[i])
+	}
+	for i := range r.IngressList {
+		fn("Ingress", &r.IngressList[i])
+	}
+	for i := range r.RoleList {
+		fn("Role", &r.RoleList[i])
+	}
+	for i := range r.RoleBindingList {
+		fn("RoleBinding", &r.RoleBindingList[i])
+	}
+	for i := range r.ClusterRoleList {
+		fn("ClusterRole", &r.ClusterRoleList[i])
+	}
+	for i := range r.ClusterRoleBindingList {
+		fn("ClusterRoleBinding", &r.ClusterRoleBindingList[i])
+	}
+	for i := range r.HpaList {
+		fn("HorizontalPodAutoscaler", &r.HpaList[i])
+	}
+	for i := range r.PspList {
+		fn("PodSecurityPolicy", &r.PspList[i])
+	}
+	for i := range r.SvcAccList {
+		fn("ServiceAccount", &r.SvcAccList[i])
+	}
+	for i := range r.CronJobList {
+		fn("CronJob", &r.CronJobList[i])
+	}
+	for i := range r.JobList {
+		fn("Job", &r.JobList[i])
+	}
+	for i := range r.PersistentVolumeClaimsList {
+		fn("PersistentVolumeClaim", &r.PersistentVolumeClaimsList[i])
+	}
+	for i := range r.MutatingWebhookConfigurationList {
+		fn("MutatingWebhookConfiguration", &r.MutatingWebhookConfigurationList[i])
+	}
+	for i := range r.ValidatingWebhookConfigurationList {
+		fn("ValidatingWebhookConfiguration", &r.ValidatingWebhookConfigurationList[i])
+	}
+}
+
+// Pod template of a workload, nil for objects that do not run pods
+func Pod_template(obj Object) *v1.PodTemplateSpec {
+	switch o := obj.(type) {
+	case *app.Deployment:
+		return &o.Spec.Template
+	case *app.DaemonSet:
+		return &o.Spec.Template
+	case *app.StatefulSet:
+		return &o.Spec.Template
+	case *batchv1.Job:
+		return &o.Spec.Template
+	case *batchv1beta1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template
+	}
+	return nil
+}
+
+// Filter removes the objects for which keep returns false
+func (r *Resources) Filter(keep func(kind string, obj Object) bool) {
+	if r.Nsl != nil {
+		items := r.Nsl.Items[:0]
+		for i := range r.Nsl.Items {
+			if keep("Namespace", &r.Nsl.Items[i]) {
+				items = append(items, r.Nsl.Items[i])
+			}
+		}
+		r.Nsl.Items = items
+	}
+	svcl := r.Svcl[:0]
+	for i := range r.Svcl {
+		if keep("Service", &r.Svcl[i]) {
+			svcl = append(svcl, r.Svcl[i])
+		}
+	}
+	r.Svcl = svcl
+	dsl := r.Dsl[:0]
+	for i := range r.Dsl {
+		if keep("DaemonSet", &r.Dsl[i]) {
+			dsl = append(dsl, r.Dsl[i])
+		}
+	}
+	r.Dsl = dsl
+	secrets := r.SecretList[:0]
+	for i := range r.SecretList {
+		if keep("Secret", &r.SecretList[i]) {
+			secrets = append(secrets, r.SecretList[i])
+		}
+	}
+	r.SecretList = secrets
+	depl := r.Depl[:0]
+	for i := range r.Depl {
+		if keep("Deployment", &r.Depl[i]) {
+			depl = append(depl, r.Depl[i])
+		}
+	}
+	r.Depl = depl
+	statefulSets := r.StatefulSetList[:0]
+	for i := range r.StatefulSetList {
+		if keep("StatefulSet", &r.StatefulSetList[i]) {
+			statefulSets = append(statefulSets, r.StatefulSetList[i])
+		}
+	}
+	r.StatefulSetList = statefulSets
+	storageClasses := r.StorageClassList[:0]
+	for i := range r.StorageClassList {
+		if keep("StorageClass", &r.StorageClassList[i]) {
+			storageClasses = append(storageClasses, r.StorageClassList[i])
+		}
+	}
+	r.StorageClassList = storageClasses
+	configMaps := r.ConfigMapsList[:0]
+	for i := range r.ConfigMapsList {
+		if keep("ConfigMap", &r.ConfigMapsList[i]) {
+			configMaps = append(configMaps, r.ConfigMapsList[i])
+		}
+	}
+	r.ConfigMapsList = configMaps
+	ingresses := r.IngressList[:0]
+	for i := range r.IngressList {
+		if keep("Ingress", &r.IngressList[i]) {
+			ingresses = append(ingresses, r.IngressList[i])
+		}
+	}
+	r.IngressList = ingresses
+	roles := r.RoleList[:0]
+	for i := range r.RoleList {
+		if keep("Role", &r.RoleList[i]) {
+			roles = append(roles, r.RoleList[i])
+		}
+	}
+	r.RoleList = roles
+	roleBindings := r.RoleBindingList[:0]
+	for i := range r.RoleBindingList {
+		if keep("RoleBinding", &r.RoleBindingList[i]) {
+			roleBindings = append(roleBindings, r.RoleBindingList[i])
+		}
+	}
+	r.RoleBindingList = roleBindings
+	clusterRoles := r.ClusterRoleList[:0]
+	for i := range r.ClusterRoleList {
+		if keep("ClusterRole", &r.ClusterRoleList[i]) {
+			clusterRoles = append(clusterRoles, r.ClusterRoleList[i])
+		}
+	}
+	r.ClusterRoleList = clusterRoles
+	clusterRoleBindings := r.ClusterRoleBindingList[:0]
+	for i := range r.ClusterRoleBindingList {
+		if keep("ClusterRoleBinding", &r.ClusterRoleBindingList[i]) {
+			clusterRoleBindings = append(clusterRoleBindings, r.ClusterRoleBindingList[i])
+		}
+	}
+	r.ClusterRoleBindingList = clusterRoleBindings
+	hpas := r.HpaList[:0]
+	for i := range r.HpaList {
+		if keep("HorizontalPodAutoscaler", &r.HpaList[i]) {
+			hpas = append(hpas, r.HpaList[i])
+		}
+	}
+	r.HpaList = hpas
+	psps := r.PspList[:0]
+	for i := range r.PspList {
+		if keep("PodSecurityPolicy", &r.PspList[i]) {
+			psps = append(psps, r.PspList[i])
+		}
+	}
+	r.PspList = psps
+	serviceAccounts := r.SvcAccList[:0]
+	for i := range r.SvcAccList {
+		if keep("ServiceAccount", &r.SvcAccList[i]) {
+			serviceAccounts = append(serviceAccounts, r.SvcAccList[i])
+		}
+	}
+	r.SvcAccList = serviceAccounts
+	cronJobs := r.CronJobList[:0]
+	for i := range r.CronJobList {
+		if keep("CronJob", &r.CronJobList[i]) {
+			cronJobs = append(cronJobs, r.CronJobList[i])
+		}
+	}
+	r.CronJobList = cronJobs
+	jobs := r.JobList[:0]
+	for i := range r.JobList {
+		if keep("Job", &r.JobList[i]) {
+			jobs = append(jobs, r.JobList[i])
+		}
+	}
+	r.JobList = jobs
+	claims := r.PersistentVolumeClaimsList[:0]
+	for i := range r.PersistentVolumeClaimsList {
+		if keep("PersistentVolumeClaim", &r.PersistentVolumeClaimsList[i]) {
+			claims = append(claims, r.PersistentVolumeClaimsList[i])
+		}
+	}
+	r.PersistentVolumeClaimsList = claims
+	mutating := r.MutatingWebhookConfigurationList[:0]
+	for i := range r.MutatingWebhookConfigurationList {
+		if keep("MutatingWebhookConfiguration", &r.MutatingWebhookConfigurationList[i]) {
+			mutating = append(mutating, r.MutatingWebhookConfigurationList[i])
+		}
+	}
+	r.MutatingWebhookConfigurationList = mutating
+	validating := r.ValidatingWebhookConfigurationList[:0]
+	for i := range r.ValidatingWebhookConfigurationList {
+		if keep("ValidatingWebhookConfiguration", &r.ValidatingWebhookConfigurationList[i]) {
+			validating = append(validating, r.ValidatingWebhookConfigurationList[i])
+		}
+	}
+	r.ValidatingWebhookConfigurationList = validating
+}
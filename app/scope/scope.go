@@ -0,0 +1,208 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package scope
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Objects annotated kmf.io/skip=true are not migrated, on a Namespace it skips everything in it
+const Skip_annotation = "kmf.io/skip"
+
+// Scope narrows what is read from the source cluster
+type Scope struct {
+	label_selector  string            // applied to every kind but Namespace
+	label_selectors map[string]string // per kind, combined with label_selector
+	field_selectors map[string]string // per kind
+	include         []name_pattern    // when some apply to a kind, its objects must match one of them
+	exclude         []name_pattern    // objects matching one of them are not migrated
+	selectors       map[string]labels.Selector
+}
+
+// name_pattern matches the object names of a kind, of every kind but Namespace when kind is empty
+type name_pattern struct {
+	kind string
+	name string
+}
+
+// Parse the scope options. Per kind selectors are written Kind:selector separated by ';',
+// name globs are separated by ',' and can be restricted to a kind with Kind/glob
+func Parse(labelSelector string, labelSelectors string, fieldSelectors string, include string, exclude string) (Scope, error) {
+	s := Scope{label_selector: strings.TrimSpace(labelSelector), selectors: make(map[string]labels.Selector)}
+	var err error
+	if s.label_selectors, err = parse_per_kind(labelSelectors); err != nil {
+		return Scope{}, err
+	}
+	if s.field_selectors, err = parse_per_kind(fieldSelectors); err != nil {
+		return Scope{}, err
+	}
+	for kind, selector := range s.field_selectors {
+		if _, err := fields.ParseSelector(selector); err != nil {
+			return Scope{}, fmt.Errorf("invalid field selector for %s: %v", kind, err)
+		}
+	}
+	for _, k := range resource.Kinds {
+		selector := s.selector(k.Kind)
+		if selector == "" {
+			continue
+		}
+		if s.selectors[k.Kind], err = labels.Parse(selector); err != nil {
+			return Scope{}, fmt.Errorf("invalid label selector for %s: %v", k.Kind, err)
+		}
+	}
+	if s.include, err = parse_names(include); err != nil {
+		return Scope{}, err
+	}
+	if s.exclude, err = parse_names(exclude); err != nil {
+		return Scope{}, err
+	}
+	return s, nil
+}
+
+// List_options pushes the selectors of the kind into the List call
+func (s Scope) List_options(kind string) metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: s.selector(kind), FieldSelector: s.field_selectors[kind]}
+}
+
+// Keep tells whether the object is in scope, the selectors are checked again for sources that ignore them
+func (s Scope) Keep(kind string, obj resource.Object) (bool, string) {
+	if strings.EqualFold(obj.GetAnnotations()[Skip_annotation], "true") {
+		return false, "annotated " + Skip_annotation + "=true"
+	}
+	if selector, ok := s.selectors[kind]; ok && !selector.Matches(labels.Set(obj.GetLabels())) {
+		return false, "does not match label selector " + selector.String()
+	}
+	included := true
+	for _, pattern := range s.include {
+		if !pattern.applies(kind) {
+			continue
+		}
+		if pattern.matches(obj.GetName()) {
+			included = true
+			break
+		}
+		included = false
+	}
+	if !included {
+		return false, "name not included"
+	}
+	for _, pattern := range s.exclude {
+		if pattern.applies(kind) && pattern.matches(obj.GetName()) {
+			return false, "name excluded by " + pattern.name
+		}
+	}
+	return true, ""
+}
+
+// Apply drops the objects out of scope
+func Apply(resources *resource.Resources, s Scope) {
+	removed := make(map[string]int)
+	resources.Filter(func(kind string, obj resource.Object) bool {
+		keep, reason := s.Keep(kind, obj)
+		if keep {
+			return true
+		}
+		removed[kind]++
+		resources.Report.Add("scope", kind, obj.GetNamespace(), obj.GetName(), reason+", not migrated")
+		return false
+	})
+
+	if len(removed) > 0 {
+		var counts []string
+		for kind, count := range removed {
+			counts = append(counts, fmt.Sprintf("%s %d", kind, count))
+		}
+		sort.Strings(counts)
+		fmt.Println("Skipped out of scope objects:", strings.Join(counts, ", "))
+	}
+}
+
+func (s Scope) selector(kind string) string {
+	var parts []string
+	if s.label_selector != "" && kind != "Namespace" {
+		parts = append(parts, s.label_selector)
+	}
+	if selector := s.label_selectors[kind]; selector != "" {
+		parts = append(parts, selector)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p name_pattern) applies(kind string) bool {
+	if p.kind == "" {
+		return kind != "Namespace"
+	}
+	return p.kind == kind
+}
+
+func (p name_pattern) matches(name string) bool {
+	ok, _ := path.Match(p.name, name)
+	return ok
+}
+
+func parse_per_kind(value string) (map[string]string, error) {
+	per_kind := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid selector %q, expected Kind:selector", entry)
+		}
+		kind := strings.TrimSpace(parts[0])
+		if _, ok := resource.Find_kind(kind); !ok {
+			return nil, fmt.Errorf("unknown kind %s in selector %q", kind, entry)
+		}
+		per_kind[kind] = strings.TrimSpace(parts[1])
+	}
+	return per_kind, nil
+}
+
+func parse_names(value string) ([]name_pattern, error) {
+	var patterns []name_pattern
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern := name_pattern{name: entry}
+		if parts := strings.SplitN(entry, "/", 2); len(parts) == 2 {
+			if _, ok := resource.Find_kind(parts[0]); !ok {
+				return nil, fmt.Errorf("unknown kind %s in name pattern %q", parts[0], entry)
+			}
+			pattern = name_pattern{kind: parts[0], name: parts[1]}
+		}
+		if _, err := path.Match(pattern.name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q", entry)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,285 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package aks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+const (
+	azure_service_prefix    = "service.beta.kubernetes.io/azure-"
+	aws_service_prefix      = "service.beta.kubernetes.io/aws-load-balancer-"
+	appgw_prefix            = "appgw.ingress.kubernetes.io/"
+	nginx_prefix            = "nginx.ingress.kubernetes.io/"
+	alb_prefix              = "alb.ingress.kubernetes.io/"
+	alb_ingress_class       = "alb"
+	certificate_placeholder = "arn:aws:acm:REGION:ACCOUNT:certificate/REPLACE-ME-%s"
+)
+
+// conversion translates one Azure annotation to its AWS Load Balancer Controller equivalent
+type conversion struct {
+	target  string                             // annotation written on the converted object
+	convert func(value string) (string, error) // converts the value, nil copies it unchanged
+}
+
+// Annotations that hold a list of key=value attributes, conversions writing them are merged instead of overwritten
+var attribute_annotations = map[string]bool{
+	alb_prefix + "load-balancer-attributes": true,
+	alb_prefix + "target-group-attributes":  true,
+}
+
+var service_conversions = map[string]conversion{
+	azure_service_prefix + "load-balancer-internal":                  {aws_service_prefix + "scheme", scheme("internal", "internet-facing")},
+	azure_service_prefix + "load-balancer-health-probe-request-path": {aws_service_prefix + "healthcheck-path", nil},
+	azure_service_prefix + "load-balancer-health-probe-protocol":     {aws_service_prefix + "healthcheck-protocol", upper},
+	azure_service_prefix + "load-balancer-health-probe-interval":     {aws_service_prefix + "healthcheck-interval", number},
+	azure_service_prefix + "load-balancer-health-probe-num-of-probe": {aws_service_prefix + "healthcheck-unhealthy-threshold", number},
+}
+
+var appgw_conversions = map[string]conversion{
+	appgw_prefix + "use-private-ip":                   {alb_prefix + "scheme", scheme("internal", "internet-facing")},
+	appgw_prefix + "ssl-redirect":                     {alb_prefix + "ssl-redirect", ssl_redirect},
+	appgw_prefix + "backend-protocol":                 {alb_prefix + "backend-protocol", upper},
+	appgw_prefix + "health-probe-path":                {alb_prefix + "healthcheck-path", nil},
+	appgw_prefix + "health-probe-port":                {alb_prefix + "healthcheck-port", nil},
+	appgw_prefix + "health-probe-interval":            {alb_prefix + "healthcheck-interval-seconds", number},
+	appgw_prefix + "health-probe-timeout":             {alb_prefix + "healthcheck-timeout-seconds", number},
+	appgw_prefix + "health-probe-unhealthy-threshold": {alb_prefix + "unhealthy-threshold-count", number},
+	appgw_prefix + "health-probe-status-codes":        {alb_prefix + "success-codes", nil},
+	appgw_prefix + "request-timeout":                  {alb_prefix + "load-balancer-attributes", attribute("idle_timeout.timeout_seconds", number)},
+	appgw_prefix + "connection-draining-timeout":      {alb_prefix + "target-group-attributes", attribute("deregistration_delay.timeout_seconds", number)},
+	appgw_prefix + "cookie-based-affinity":            {alb_prefix + "target-group-attributes", stickiness},
+	appgw_prefix + "appgw-ssl-certificate":            {alb_prefix + "certificate-arn", certificate},
+	appgw_prefix + "appgw-trusted-root-certificate":   {"", nil},
+	appgw_prefix + "connection-draining":              {"", nil},
+	appgw_prefix + "override-frontend-port":           {"", nil},
+}
+
+var nginx_conversions = map[string]conversion{
+	nginx_prefix + "ssl-redirect":           {alb_prefix + "ssl-redirect", ssl_redirect},
+	nginx_prefix + "force-ssl-redirect":     {alb_prefix + "ssl-redirect", ssl_redirect},
+	nginx_prefix + "backend-protocol":       {alb_prefix + "backend-protocol", upper},
+	nginx_prefix + "whitelist-source-range": {alb_prefix + "inbound-cidrs", nil},
+	nginx_prefix + "proxy-read-timeout":     {alb_prefix + "load-balancer-attributes", attribute("idle_timeout.timeout_seconds", number)},
+	nginx_prefix + "affinity":               {alb_prefix + "target-group-attributes", stickiness},
+	nginx_prefix + "session-cookie-max-age": {alb_prefix + "target-group-attributes", attribute("stickiness.lb_cookie.duration_seconds", number)},
+	nginx_prefix + "session-cookie-name":    {"", nil},
+	nginx_prefix + "proxy-connect-timeout":  {"", nil},
+	nginx_prefix + "proxy-send-timeout":     {"", nil},
+}
+
+// Convert the annotations of AKS Services and Ingresses to the AWS Load Balancer Controller, unmapped annotations are reported
+func Convert_annotations(resources *resource.Resources) {
+	fmt.Println("Converting AKS annotations....start")
+	for i := range resources.Svcl {
+		convert_service(resources, &resources.Svcl[i])
+	}
+	for i := range resources.IngressList {
+		convert_ingress(resources, &resources.IngressList[i])
+	}
+	fmt.Println("Converting AKS annotations....End")
+}
+
+func convert_service(resources *resource.Resources, svc *v1.Service) {
+	report := func(message string) {
+		resources.Report.Add("aks-annotations", "Service", svc.ObjectMeta.Namespace, svc.ObjectMeta.Name, message)
+	}
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return
+	}
+
+	annotations := convert_table(svc.ObjectMeta.Annotations, service_conversions, []string{azure_service_prefix}, report)
+	set_default(annotations, aws_service_prefix+"type", "external")
+	set_default(annotations, aws_service_prefix+"nlb-target-type", "ip")
+	set_default(annotations, aws_service_prefix+"scheme", "internet-facing")
+	svc.ObjectMeta.Annotations = annotations
+
+	if svc.Spec.LoadBalancerIP != "" {
+		report(fmt.Sprintf("loadBalancerIP %s is not supported by NLB and was removed, allocate Elastic IPs with %seip-allocations", svc.Spec.LoadBalancerIP, aws_service_prefix))
+		svc.Spec.LoadBalancerIP = ""
+	}
+}
+
+func convert_ingress(resources *resource.Resources, ing *networking.Ingress) {
+	report := func(message string) {
+		resources.Report.Add("aks-annotations", "Ingress", ing.ObjectMeta.Namespace, ing.ObjectMeta.Name, message)
+	}
+
+	var table map[string]conversion
+	var prefixes []string
+	switch ingress_class(ing) {
+	// the annotation takes azure/application-gateway, the IngressClass the add-on creates is azure-application-gateway
+	case "azure/application-gateway", "azure-application-gateway":
+		table, prefixes = appgw_conversions, []string{appgw_prefix}
+	case "nginx":
+		table, prefixes = nginx_conversions, []string{nginx_prefix}
+	default:
+		return
+	}
+
+	annotations := convert_table(ing.ObjectMeta.Annotations, table, prefixes, report)
+	delete(annotations, "kubernetes.io/ingress.class")
+	class := alb_ingress_class
+	ing.Spec.IngressClassName = &class
+
+	set_default(annotations, alb_prefix+"scheme", "internet-facing")
+	set_default(annotations, alb_prefix+"target-type", "ip")
+	_, certificates := annotations[alb_prefix+"certificate-arn"]
+	if len(ing.Spec.TLS) > 0 && !certificates {
+		report(fmt.Sprintf("TLS secrets are not used by ALB, import the certificates to ACM and set %scertificate-arn", alb_prefix))
+	}
+	if _, redirect := annotations[alb_prefix+"ssl-redirect"]; redirect || certificates || len(ing.Spec.TLS) > 0 {
+		set_default(annotations, alb_prefix+"listen-ports", `[{"HTTP":80},{"HTTPS":443}]`)
+	}
+	ing.ObjectMeta.Annotations = annotations
+}
+
+func ingress_class(ing *networking.Ingress) string {
+	if class, ok := ing.ObjectMeta.Annotations["kubernetes.io/ingress.class"]; ok {
+		return class
+	}
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+	return ""
+}
+
+// Apply a conversion table to a set of annotations, annotations under the given prefixes that are not in the table are dropped and reported
+func convert_table(from map[string]string, table map[string]conversion, prefixes []string, report func(string)) map[string]string {
+	annotations := make(map[string]string)
+	var keys []string
+	for key, value := range from {
+		if has_prefix(key, prefixes) {
+			keys = append(keys, key)
+		} else {
+			annotations[key] = value
+		}
+	}
+	// sorted so that merged attribute lists and the report are stable between runs
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := from[key]
+		rule, ok := table[key]
+		if !ok || rule.target == "" {
+			report(fmt.Sprintf("annotation %s=%s has no AWS equivalent and was dropped", key, value))
+			continue
+		}
+		if rule.convert != nil {
+			converted, err := rule.convert(value)
+			if err != nil {
+				report(fmt.Sprintf("annotation %s=%s was dropped: %v", key, value, err))
+				continue
+			}
+			value = converted
+		}
+		if value == "" {
+			continue
+		}
+		if existing, ok := annotations[rule.target]; ok && attribute_annotations[rule.target] {
+			value = existing + "," + value
+		}
+		annotations[rule.target] = value
+		if strings.Contains(value, "REPLACE-ME") {
+			report(fmt.Sprintf("replace the placeholder in %s=%s", rule.target, value))
+		}
+	}
+	return annotations
+}
+
+func has_prefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keep annotations the user already set for the AWS Load Balancer Controller
+func set_default(annotations map[string]string, key string, value string) {
+	if _, ok := annotations[key]; !ok {
+		annotations[key] = value
+	}
+}
+
+func scheme(whenTrue string, whenFalse string) func(string) (string, error) {
+	return func(value string) (string, error) {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			return whenTrue, nil
+		}
+		return whenFalse, nil
+	}
+}
+
+func attribute(name string, convert func(string) (string, error)) func(string) (string, error) {
+	return func(value string) (string, error) {
+		converted, err := convert(value)
+		if err != nil {
+			return "", err
+		}
+		return name + "=" + converted, nil
+	}
+}
+
+func upper(value string) (string, error) {
+	return strings.ToUpper(strings.TrimSpace(value)), nil
+}
+
+func number(value string) (string, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "s")
+	if _, err := strconv.Atoi(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func ssl_redirect(value string) (string, error) {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil || !enabled {
+		return "", err
+	}
+	return "443", nil
+}
+
+func stickiness(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "enabled", "true", "cookie":
+		return "stickiness.enabled=true,stickiness.type=lb_cookie", nil
+	case "disabled", "false", "":
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown affinity %s", value)
+}
+
+func certificate(value string) (string, error) {
+	return fmt.Sprintf(certificate_placeholder, value), nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package generic
+
+import (
+	cluster "containers-migration-factory/app/cluster"
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+	source_impl "containers-migration-factory/app/source/source_impl"
+	"fmt"
+)
+
+// GENERIC reads any conformant Kubernetes cluster, the distribution selects the system components that are skipped
+type GENERIC struct{}
+
+func (c GENERIC) Connect(sCluster *cluster.Cluster) {
+	sCluster.Generate_cluster_client()
+}
+
+// GENERIC GetSourceDetails implements the Source interface
+func (g GENERIC) GetSourceDetails(sCluster *cluster.Cluster) resource.Resources {
+	fmt.Println("GENERIC GetSourceDetails....")
+	resources := resource.Resources{Report: report.New()}
+	source_impl.Generate_namespace_list(sCluster, &resources)
+
+	source_impl.Generate_helm_charts(sCluster, &resources)
+	source_impl.Package_helm_charts(sCluster, &resources)
+
+	source_impl.Generate_namespaced_configs(sCluster, &resources)
+	source_impl.Generate_mutatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_validatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_storage_class_config(sCluster, &resources)
+	source_impl.Generate_psp_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_binding_config(sCluster, &resources)
+
+	return resources
+}
+
+// GENERIC FormatSourceData implements the Source interface
+func (g GENERIC) FormatSourceData(resource *resource.Resources, resToInclude []string) {
+	fmt.Println("GENERIC FormatSourceData....start")
+	source_impl.Resource_trim_fields("Namespace", resource, resToInclude)
+	source_impl.Resource_trim_fields("DaemonSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("MutatingWebhookConfiguration", resource, resToInclude)
+	source_impl.Resource_trim_fields("Deployment", resource, resToInclude)
+	source_impl.Resource_trim_fields("StatefulSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("Service", resource, resToInclude)
+	source_impl.Resource_trim_fields("Secrets", resource, resToInclude)
+	source_impl.Resource_trim_fields("StorageClasses", resource, resToInclude)
+	source_impl.Resource_trim_fields("Roles", resource, resToInclude)
+	source_impl.Resource_trim_fields("RoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoles", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("HorizontalPodAutoscaler", resource, resToInclude)
+	source_impl.Resource_trim_fields("PodSecurityPolicy", resource, resToInclude)
+	source_impl.Resource_trim_fields("ServiceAccount", resource, resToInclude)
+	source_impl.Resource_trim_fields("PersistentVolumeClaim", resource, resToInclude)
+	source_impl.Resource_trim_fields("CronJob", resource, resToInclude)
+	source_impl.Resource_trim_fields("Job", resource, resToInclude)
+	source_impl.Resource_trim_fields("ConfigMap", resource, resToInclude)
+	source_impl.Resource_trim_fields("Ingress", resource, resToInclude)
+	fmt.Println("GENERIC FormatSourceData....End")
+
+}
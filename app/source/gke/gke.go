@@ -21,6 +21,7 @@ package gke
 
 import (
 	cluster "containers-migration-factory/app/cluster"
+	report "containers-migration-factory/app/report"
 	resource "containers-migration-factory/app/resource"
 	source_impl "containers-migration-factory/app/source/source_impl"
 	"fmt"
@@ -37,36 +38,24 @@ func (c GKE) Connect(sCluster *cluster.Cluster) {
 // GCP GetSourceDetails implements the Source interface
 func (g GKE) GetSourceDetails(sCluster *cluster.Cluster) resource.Resources {
 	fmt.Println("GKE GetSourceDetails....")
-	resources := resource.Resources{}
+	resources := resource.Resources{Report: report.New()}
 	source_impl.Generate_namespace_list(sCluster, &resources)
 
 	source_impl.Generate_helm_charts(sCluster, &resources)
+	source_impl.Package_helm_charts(sCluster, &resources)
 
-	source_impl.Generate_job_config(sCluster, &resources)
-
-	source_impl.Generate_cronjob_config(sCluster, &resources)
-	source_impl.Generate_secret_config(sCluster, &resources)
-	source_impl.Generate_configmap_config(sCluster, &resources)
+	source_impl.Generate_namespaced_configs(sCluster, &resources)
 	source_impl.Generate_mutatingwebhook_config(sCluster, &resources)
 	source_impl.Generate_validatingwebhook_config(sCluster, &resources)
-	source_impl.Generate_ingress_config(sCluster, &resources)
 	source_impl.Generate_storage_class_config(sCluster, &resources)
-	source_impl.Generate_pvc_config(sCluster, &resources)
-	source_impl.Generate_deployment_config(sCluster, &resources)
-
-	source_impl.Generate_service_config(sCluster, &resources)
-	source_impl.Generate_daemonset_config(sCluster, &resources)
-	source_impl.Generate_hpa_config(sCluster, &resources)
 	source_impl.Generate_psp_config(sCluster, &resources)
-	source_impl.Generate_serviceaccount_config(sCluster, &resources)
-	source_impl.Generate_role_config(sCluster, &resources)
-	source_impl.Generate_role_binding_config(sCluster, &resources)
 	source_impl.Generate_cluster_role_config(sCluster, &resources)
 	source_impl.Generate_cluster_role_binding_config(sCluster, &resources)
 
 	if log {
 		fmt.Println("......JobList......", resources.JobList)
 		fmt.Println("......Deployments......", resources.Depl)
+		fmt.Println("......StatefulSets......", resources.StatefulSetList)
 		fmt.Println("......DaemonSet......", resources.Dsl)
 		fmt.Println("......ServiceList......", resources.Svcl)
 		fmt.Println("......StorageClassList......", resources.StorageClassList)
@@ -98,6 +87,7 @@ func (g GKE) FormatSourceData(resource *resource.Resources, resToInclude []strin
 	source_impl.Resource_trim_fields("DaemonSet", resource, resToInclude)
 	source_impl.Resource_trim_fields("MutatingWebhookConfiguration", resource, resToInclude)
 	source_impl.Resource_trim_fields("Deployment", resource, resToInclude)
+	source_impl.Resource_trim_fields("StatefulSet", resource, resToInclude)
 	source_impl.Resource_trim_fields("Service", resource, resToInclude)
 	source_impl.Resource_trim_fields("Secrets", resource, resToInclude)
 	source_impl.Resource_trim_fields("StorageClasses", resource, resToInclude)
@@ -113,6 +103,7 @@ func (g GKE) FormatSourceData(resource *resource.Resources, resToInclude []strin
 	source_impl.Resource_trim_fields("Job", resource, resToInclude)
 	source_impl.Resource_trim_fields("ConfigMap", resource, resToInclude)
 	source_impl.Resource_trim_fields("Ingress", resource, resToInclude)
+	Convert_ingresses(resource)
 	fmt.Println("GKE FormatSourceData....End")
 
 }
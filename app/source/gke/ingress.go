@@ -0,0 +1,328 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package gke
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+const (
+	ingress_class_annotation = "kubernetes.io/ingress.class"
+	alb_ingress_class        = "alb"
+	alb_annotation_prefix    = "alb.ingress.kubernetes.io/"
+	neg_annotation           = "cloud.google.com/neg"
+	certificate_placeholder  = "arn:aws:acm:REGION:ACCOUNT:certificate/REPLACE-ME-%s"
+)
+
+// Annotations written by the GKE ingress controller to record the load balancer it created, they are dropped silently
+var gke_status_annotations = []string{
+	"ingress.kubernetes.io/backends",
+	"ingress.kubernetes.io/forwarding-rule",
+	"ingress.kubernetes.io/https-forwarding-rule",
+	"ingress.kubernetes.io/https-target-proxy",
+	"ingress.kubernetes.io/ssl-cert",
+	"ingress.kubernetes.io/static-ip",
+	"ingress.kubernetes.io/target-proxy",
+	"ingress.kubernetes.io/url-map",
+	"ingress.kubernetes.io/redirect-url-map",
+}
+
+// GKE annotations with no AWS Load Balancer Controller equivalent, they are dropped and reported with the given hint
+var gke_unsupported_annotations = map[string]string{
+	"kubernetes.io/ingress.global-static-ip-name":   "ALB does not support static IPs, use AWS Global Accelerator or a DNS alias instead",
+	"kubernetes.io/ingress.regional-static-ip-name": "ALB does not support static IPs, use AWS Global Accelerator or a DNS alias instead",
+	"networking.gke.io/v1beta1.FrontendConfig":      "FrontendConfig is not migrated, set the equivalent alb.ingress.kubernetes.io annotations by hand",
+	"networking.gke.io/suppress-firewall-xpn-error": "",
+}
+
+// Service annotations only understood by GKE
+var gke_service_annotations = map[string]string{
+	"cloud.google.com/backend-config":      "BackendConfig is not migrated, set the equivalent alb.ingress.kubernetes.io annotations on the Service by hand",
+	"beta.cloud.google.com/backend-config": "BackendConfig is not migrated, set the equivalent alb.ingress.kubernetes.io annotations on the Service by hand",
+	"cloud.google.com/app-protocols":       "app-protocols is not migrated, set alb.ingress.kubernetes.io/backend-protocol on the Service by hand",
+	neg_annotation:                         "",
+	"cloud.google.com/neg-status":          "",
+}
+
+// Convert the Ingresses served by the GKE ingress controller to the AWS Load Balancer Controller
+func Convert_ingresses(resources *resource.Resources) {
+	fmt.Println("Converting GKE Ingresses....start")
+	services := make(map[string]*v1.Service)
+	for i := range resources.Svcl {
+		svc := &resources.Svcl[i]
+		services[svc.ObjectMeta.Namespace+"/"+svc.ObjectMeta.Name] = svc
+	}
+
+	converted := make(map[string]bool)
+	for i := range resources.IngressList {
+		ing := &resources.IngressList[i]
+		class := ingress_class(ing)
+		if class != "gce" && class != "gce-internal" {
+			continue
+		}
+		convert_ingress(resources, ing, class, services)
+		for _, name := range ingress_services(ing) {
+			converted[ing.ObjectMeta.Namespace+"/"+name] = true
+		}
+	}
+
+	for key := range converted {
+		svc, ok := services[key]
+		if !ok {
+			continue
+		}
+		convert_backend_service(resources, svc)
+	}
+	fmt.Println("Converting GKE Ingresses....End")
+}
+
+// GKE serves Ingresses without a class with its own controller
+func ingress_class(ing *networking.Ingress) string {
+	if class, ok := ing.ObjectMeta.Annotations[ingress_class_annotation]; ok {
+		return class
+	}
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+	return "gce"
+}
+
+func convert_ingress(resources *resource.Resources, ing *networking.Ingress, class string, services map[string]*v1.Service) {
+	namespace, name := ing.ObjectMeta.Namespace, ing.ObjectMeta.Name
+	report := func(message string) {
+		resources.Report.Add("gke-ingress", "Ingress", namespace, name, message)
+	}
+
+	annotations := make(map[string]string)
+	for key, value := range ing.ObjectMeta.Annotations {
+		annotations[key] = value
+	}
+	converted := make(map[string]string)
+
+	delete(annotations, ingress_class_annotation)
+	albClass := alb_ingress_class
+	ing.Spec.IngressClassName = &albClass
+
+	if class == "gce-internal" {
+		converted["scheme"] = "internal"
+	} else {
+		converted["scheme"] = "internet-facing"
+	}
+	converted["target-type"] = target_type(ing, services)
+
+	var certificates []string
+	if value, ok := annotations["networking.gke.io/managed-certificates"]; ok {
+		delete(annotations, "networking.gke.io/managed-certificates")
+		certificates = append(certificates, split_list(value)...)
+	}
+	if value, ok := annotations["ingress.gcp.kubernetes.io/pre-shared-cert"]; ok {
+		delete(annotations, "ingress.gcp.kubernetes.io/pre-shared-cert")
+		certificates = append(certificates, split_list(value)...)
+	}
+	var arns []string
+	for _, certificate := range certificates {
+		arns = append(arns, fmt.Sprintf(certificate_placeholder, certificate))
+	}
+	if len(arns) > 0 {
+		converted["certificate-arn"] = strings.Join(arns, ",")
+		report(fmt.Sprintf("replace the certificate ARN placeholders for %s with ACM certificates", strings.Join(certificates, ", ")))
+	} else if len(ing.Spec.TLS) > 0 {
+		report("TLS secrets are not used by ALB, import the certificates to ACM and set alb.ingress.kubernetes.io/certificate-arn")
+	}
+
+	https := len(arns) > 0 || len(ing.Spec.TLS) > 0
+	allowHttp, ok := annotations["kubernetes.io/ingress.allow-http"]
+	delete(annotations, "kubernetes.io/ingress.allow-http")
+	switch {
+	case ok && allowHttp == "false":
+		converted["listen-ports"] = `[{"HTTPS":443}]`
+	case https:
+		converted["listen-ports"] = `[{"HTTP":80},{"HTTPS":443}]`
+	}
+
+	for _, key := range gke_status_annotations {
+		delete(annotations, key)
+	}
+	for key, hint := range gke_unsupported_annotations {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		delete(annotations, key)
+		if hint != "" {
+			report(fmt.Sprintf("annotation %s=%s dropped: %s", key, value, hint))
+		}
+	}
+	for key, value := range annotations {
+		if strings.HasPrefix(key, "ingress.gcp.kubernetes.io/") || strings.HasPrefix(key, "networking.gke.io/") || strings.HasPrefix(key, "cloud.google.com/") {
+			delete(annotations, key)
+			report(fmt.Sprintf("annotation %s=%s has no ALB equivalent and was dropped", key, value))
+		}
+	}
+
+	for key, value := range converted {
+		if _, ok := annotations[alb_annotation_prefix+key]; ok {
+			continue
+		}
+		annotations[alb_annotation_prefix+key] = value
+	}
+	ing.ObjectMeta.Annotations = annotations
+}
+
+// ALB targets pods directly unless every backend is exposed on the nodes without container native load balancing
+func target_type(ing *networking.Ingress, services map[string]*v1.Service) string {
+	for _, name := range ingress_services(ing) {
+		svc, ok := services[ing.ObjectMeta.Namespace+"/"+name]
+		if !ok {
+			continue
+		}
+		if _, neg := svc.ObjectMeta.Annotations[neg_annotation]; neg {
+			return "ip"
+		}
+		if svc.Spec.Type != v1.ServiceTypeNodePort && svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+			return "ip"
+		}
+	}
+	return "instance"
+}
+
+// Names of the Services an Ingress sends traffic to
+func ingress_services(ing *networking.Ingress) []string {
+	var names []string
+	add := func(backend *networking.IngressBackend) {
+		if backend == nil || backend.Service == nil {
+			return
+		}
+		names = append(names, backend.Service.Name)
+	}
+	add(ing.Spec.DefaultBackend)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			add(&rule.HTTP.Paths[i].Backend)
+		}
+	}
+	return names
+}
+
+// Drop the GKE annotations of a Service behind a converted Ingress and carry over the health check path
+func convert_backend_service(resources *resource.Resources, svc *v1.Service) {
+	for key, hint := range gke_service_annotations {
+		value, ok := svc.ObjectMeta.Annotations[key]
+		if !ok {
+			continue
+		}
+		delete(svc.ObjectMeta.Annotations, key)
+		if hint != "" {
+			resources.Report.Add("gke-ingress", "Service", svc.ObjectMeta.Namespace, svc.ObjectMeta.Name, fmt.Sprintf("annotation %s=%s dropped: %s", key, value, hint))
+		}
+	}
+
+	// GKE builds the health check from the readiness probe of the serving pods, ALB needs it spelled out
+	path := health_check_path(resources, svc)
+	if path == "" {
+		return
+	}
+	if svc.ObjectMeta.Annotations == nil {
+		svc.ObjectMeta.Annotations = make(map[string]string)
+	}
+	if _, ok := svc.ObjectMeta.Annotations[alb_annotation_prefix+"healthcheck-path"]; !ok {
+		svc.ObjectMeta.Annotations[alb_annotation_prefix+"healthcheck-path"] = path
+	}
+}
+
+// Path of the HTTP readiness probe of the pods selected by a Service, empty when there is none
+func health_check_path(resources *resource.Resources, svc *v1.Service) string {
+	if len(svc.Spec.Selector) == 0 {
+		return ""
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+
+	// pod templates do not carry a namespace, take it from the owning workload
+	var templates []*v1.PodTemplateSpec
+	for i := range resources.Depl {
+		if resources.Depl[i].ObjectMeta.Namespace == svc.ObjectMeta.Namespace {
+			templates = append(templates, &resources.Depl[i].Spec.Template)
+		}
+	}
+	for i := range resources.Dsl {
+		if resources.Dsl[i].ObjectMeta.Namespace == svc.ObjectMeta.Namespace {
+			templates = append(templates, &resources.Dsl[i].Spec.Template)
+		}
+	}
+	for i := range resources.StatefulSetList {
+		if resources.StatefulSetList[i].ObjectMeta.Namespace == svc.ObjectMeta.Namespace {
+			templates = append(templates, &resources.StatefulSetList[i].Spec.Template)
+		}
+	}
+
+	paths := make(map[string]bool)
+	for _, template := range templates {
+		if !selector.Matches(labels.Set(template.ObjectMeta.Labels)) {
+			continue
+		}
+		for _, container := range template.Spec.Containers {
+			probe := container.ReadinessProbe
+			if probe != nil && probe.HTTPGet != nil && probe.HTTPGet.Path != "" {
+				paths[probe.HTTPGet.Path] = true
+			}
+		}
+	}
+
+	if len(paths) != 1 {
+		if len(paths) > 1 {
+			var found []string
+			for path := range paths {
+				found = append(found, path)
+			}
+			sort.Strings(found)
+			resources.Report.Add("gke-ingress", "Service", svc.ObjectMeta.Namespace, svc.ObjectMeta.Name, fmt.Sprintf("pods use different readiness probe paths %s, set alb.ingress.kubernetes.io/healthcheck-path by hand", strings.Join(found, ", ")))
+		}
+		return ""
+	}
+	for path := range paths {
+		return path
+	}
+	return ""
+}
+
+// Managed certificate annotations hold a comma separated list, pre-shared certificates may also be given as JSON
+func split_list(value string) []string {
+	var list []string
+	if json.Unmarshal([]byte(value), &list) == nil {
+		return list
+	}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package kops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+const (
+	instance_group_label = "kops.k8s.io/instancegroup"
+	in_tree_ebs          = "kubernetes.io/aws-ebs"
+	ebs_csi              = "ebs.csi.aws.com"
+	zone_label           = "topology.kubernetes.io/zone"
+)
+
+// Parameters of the in-tree EBS provisioner that the EBS CSI driver takes unchanged
+var ebs_csi_parameters = map[string]bool{"type": true, "iopsPerGB": true, "encrypted": true, "kmsKeyId": true}
+
+// Zone topology keys used by the in-tree EBS provisioner
+var zone_keys = map[string]bool{"failure-domain.beta.kubernetes.io/zone": true, "topology.ebs.csi.aws.com/zone": true, zone_label: true}
+
+// Move the StorageClasses of the in-tree EBS provisioner to the EBS CSI driver
+func Convert_storage_classes(resources *resource.Resources) {
+	for i := range resources.StorageClassList {
+		sc := &resources.StorageClassList[i]
+		if sc.Provisioner != in_tree_ebs {
+			continue
+		}
+		report := func(message string) {
+			resources.Report.Add("kops", "StorageClass", "", sc.ObjectMeta.Name, message)
+		}
+
+		// the in-tree provisioner defaults to gp2, the CSI driver to gp3
+		parameters := map[string]string{"type": "gp2"}
+		var zones []string
+		var dropped []string
+		for key, value := range sc.Parameters {
+			switch {
+			case ebs_csi_parameters[key]:
+				parameters[key] = value
+			case key == "fsType":
+				parameters["csi.storage.k8s.io/fstype"] = value
+			case key == "zone" || key == "zones":
+				for _, zone := range strings.Split(value, ",") {
+					if zone = strings.TrimSpace(zone); zone != "" {
+						zones = append(zones, zone)
+					}
+				}
+			default:
+				dropped = append(dropped, key)
+			}
+		}
+		if len(dropped) > 0 {
+			sort.Strings(dropped)
+			report(fmt.Sprintf("parameters %s are not supported by the EBS CSI driver and were dropped", strings.Join(dropped, ", ")))
+		}
+
+		for j := range sc.AllowedTopologies {
+			for k := range sc.AllowedTopologies[j].MatchLabelExpressions {
+				expression := &sc.AllowedTopologies[j].MatchLabelExpressions[k]
+				if zone_keys[expression.Key] {
+					expression.Key = zone_label
+				}
+			}
+		}
+		if len(zones) > 0 && len(sc.AllowedTopologies) == 0 {
+			sc.AllowedTopologies = []v1.TopologySelectorTerm{{
+				MatchLabelExpressions: []v1.TopologySelectorLabelRequirement{{Key: zone_label, Values: zones}},
+			}}
+		}
+
+		fmt.Printf("StorageClass %s: %s -> %s\n", sc.ObjectMeta.Name, in_tree_ebs, ebs_csi)
+		sc.Provisioner = ebs_csi
+		sc.Parameters = parameters
+		if sc.VolumeBindingMode == nil {
+			// the CSI driver needs the pod's zone to create the volume next to it
+			mode := storage.VolumeBindingWaitForFirstConsumer
+			sc.VolumeBindingMode = &mode
+		}
+	}
+}
+
+// Remove scheduling constraints on kops instance groups, they do not exist in the EKS cluster
+func Remove_instance_group_selectors(resources *resource.Resources) {
+	resources.Each(func(kind string, obj resource.Object) {
+		template := resource.Pod_template(obj)
+		if template == nil {
+			return
+		}
+		report := func(message string) {
+			resources.Report.Add("kops", kind, obj.GetNamespace(), obj.GetName(), message)
+		}
+		spec := &template.Spec
+
+		if group, ok := spec.NodeSelector[instance_group_label]; ok {
+			delete(spec.NodeSelector, instance_group_label)
+			report(fmt.Sprintf("nodeSelector %s=%s removed, select an EKS node group with eks.amazonaws.com/nodegroup instead", instance_group_label, group))
+		}
+
+		if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+			return
+		}
+		affinity := spec.Affinity.NodeAffinity
+		if required := affinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			var terms []v1.NodeSelectorTerm
+			for _, term := range required.NodeSelectorTerms {
+				if term.MatchExpressions = without_instance_group(term.MatchExpressions, report); len(term.MatchExpressions) > 0 || len(term.MatchFields) > 0 {
+					terms = append(terms, term)
+				}
+			}
+			required.NodeSelectorTerms = terms
+			if len(terms) == 0 {
+				affinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+			}
+		}
+		var preferred []v1.PreferredSchedulingTerm
+		for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			term.Preference.MatchExpressions = without_instance_group(term.Preference.MatchExpressions, report)
+			if len(term.Preference.MatchExpressions) > 0 || len(term.Preference.MatchFields) > 0 {
+				preferred = append(preferred, term)
+			}
+		}
+		affinity.PreferredDuringSchedulingIgnoredDuringExecution = preferred
+	})
+}
+
+func without_instance_group(expressions []v1.NodeSelectorRequirement, report func(string)) []v1.NodeSelectorRequirement {
+	var kept []v1.NodeSelectorRequirement
+	for _, expression := range expressions {
+		if expression.Key == instance_group_label {
+			report(fmt.Sprintf("node affinity on %s %s removed, select an EKS node group with eks.amazonaws.com/nodegroup instead", instance_group_label, strings.Join(expression.Values, ",")))
+			continue
+		}
+		kept = append(kept, expression)
+	}
+	return kept
+}
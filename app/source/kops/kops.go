@@ -21,7 +21,9 @@ package kops
 
 import (
 	cluster "containers-migration-factory/app/cluster"
+	report "containers-migration-factory/app/report"
 	resource "containers-migration-factory/app/resource"
+	source_impl "containers-migration-factory/app/source/source_impl"
 	"fmt"
 )
 
@@ -34,11 +36,47 @@ func (c KOPS) Connect(sCluster *cluster.Cluster) {
 
 func (k KOPS) GetSourceDetails(sCluster *cluster.Cluster) resource.Resources {
 	fmt.Println("KOPS GetSourceDetails....")
-	resources := resource.Resources{}
+	resources := resource.Resources{Report: report.New()}
+	source_impl.Generate_namespace_list(sCluster, &resources)
+
+	source_impl.Generate_helm_charts(sCluster, &resources)
+	source_impl.Package_helm_charts(sCluster, &resources)
+
+	source_impl.Generate_namespaced_configs(sCluster, &resources)
+	source_impl.Generate_mutatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_validatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_storage_class_config(sCluster, &resources)
+	source_impl.Generate_psp_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_binding_config(sCluster, &resources)
 
 	return resources
 }
 
-// GCP FormatSourceData implements the Geometry interface
+// KOPS FormatSourceData implements the Source interface
 func (k KOPS) FormatSourceData(resource *resource.Resources, resToInclude []string) {
+	fmt.Println("KOPS FormatSourceData....start")
+	source_impl.Resource_trim_fields("Namespace", resource, resToInclude)
+	source_impl.Resource_trim_fields("DaemonSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("MutatingWebhookConfiguration", resource, resToInclude)
+	source_impl.Resource_trim_fields("Deployment", resource, resToInclude)
+	source_impl.Resource_trim_fields("StatefulSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("Service", resource, resToInclude)
+	source_impl.Resource_trim_fields("Secrets", resource, resToInclude)
+	source_impl.Resource_trim_fields("StorageClasses", resource, resToInclude)
+	source_impl.Resource_trim_fields("Roles", resource, resToInclude)
+	source_impl.Resource_trim_fields("RoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoles", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("HorizontalPodAutoscaler", resource, resToInclude)
+	source_impl.Resource_trim_fields("PodSecurityPolicy", resource, resToInclude)
+	source_impl.Resource_trim_fields("ServiceAccount", resource, resToInclude)
+	source_impl.Resource_trim_fields("PersistentVolumeClaim", resource, resToInclude)
+	source_impl.Resource_trim_fields("CronJob", resource, resToInclude)
+	source_impl.Resource_trim_fields("Job", resource, resToInclude)
+	source_impl.Resource_trim_fields("ConfigMap", resource, resToInclude)
+	source_impl.Resource_trim_fields("Ingress", resource, resToInclude)
+	Convert_storage_classes(resource)
+	Remove_instance_group_selectors(resource)
+	fmt.Println("KOPS FormatSourceData....End")
 }
@@ -21,8 +21,17 @@ package source
 
 import (
 	// "fmt"
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+
+	application "containers-migration-factory/app/application"
+	checkpoint "containers-migration-factory/app/checkpoint"
 	cluster "containers-migration-factory/app/cluster"
+	ignore "containers-migration-factory/app/ignore"
+	scope "containers-migration-factory/app/scope"
 	resource "containers-migration-factory/app/resource"
+	transform "containers-migration-factory/app/transform"
 )
 
 // Geometry is an interface that defines Geometrical Calculation
@@ -38,13 +47,70 @@ func SetContext(source Source,sCluster *cluster.Cluster){
 }
 
 // Invoke specific source based on input provided
-func Invoke(source Source, sType string, sCluster *cluster.Cluster, dCluster *cluster.Cluster) resource.Resources {
+func Invoke(ctx context.Context, source Source, sType string, sCluster *cluster.Cluster, dCluster *cluster.Cluster) resource.Resources {
+
+	/*Cancelling ctx stops the scan and writes the checkpoint*/
+
+	sCluster.SetCtx(ctx)
+	dCluster.SetCtx(ctx)
+	sCluster.GetCheckpoint().Start("scan")
 
 	/*Get Source Details*/
 
 	resources := source.GetSourceDetails(sCluster)
+	sCluster.Exit_if_interrupted()
+
+	/*Skip the system components the destination cluster brings itself*/
+
+	ignore.Remove_system_components(&resources, sCluster.GetIgnore())
+	scope.Apply(&resources, sCluster.GetScope())
+	application.Select(&resources, sCluster.GetApplication())
 
 	source.FormatSourceData(&resources, sCluster.Resources)
 
+	/*Adapt the source objects to the destination cluster*/
+
+	transform.Apply_rules(&resources, dCluster.GetRules(), dCluster.GetExplain())
+	transform.Map_storage_classes(&resources, dCluster.GetStorage_class_mapping())
+	transform.Remap_namespaces(&resources, dCluster.GetNamespace_mapping())
+	transform.Rename_objects(&resources, dCluster.GetName_prefix(), dCluster.GetName_suffix())
+	transform.Inject_labels(&resources, dCluster.GetLabels(), dCluster.GetAnnotations())
+
+	/*Record what the run migrates under the destination names*/
+
+	record_scanned(&resources, sCluster)
+
 	return resources
 }
+
+func record_scanned(resources *resource.Resources, sCluster *cluster.Cluster) {
+	state := sCluster.GetCheckpoint()
+	images := sCluster.GetMigrate_Images() == "Yes" || sCluster.GetMigrate_Images() == "yes"
+	resources.Each(func(kind string, obj resource.Object) {
+		status := checkpoint.Scanned
+		if images && images_copied(state, resource.Pod_template(obj)) {
+			status = checkpoint.Images_copied
+		}
+		state.Set(kind, obj.GetNamespace(), obj.GetName(), status)
+	})
+	for namespace, charts := range resources.HelmList {
+		for release := range charts {
+			state.Set("HelmRelease", namespace, release, checkpoint.Scanned)
+		}
+	}
+	state.Flush()
+}
+
+// The images of a pod template were copied when every container runs the ECR copy of its source image, the images
+// Validate skipped stay in the source registry
+func images_copied(state *checkpoint.Checkpoint, template *v1.PodTemplateSpec) bool {
+	if template == nil || len(template.Spec.Containers) == 0 {
+		return false
+	}
+	for _, container := range template.Spec.Containers {
+		if !state.Copied(container.Image) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package source_impl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	helm "helm.sh/helm/v3/pkg/release"
+
+	cluster "containers-migration-factory/app/cluster"
+)
+
+// Table and owner Helm stores the release revisions with in its SQL storage
+const (
+	helm_sql_table = "releases_v1"
+	helm_sql_owner = "helm"
+)
+
+// Storage driver of the Helm releases, HELM_DRIVER of config.ini or else of the environment, in lower case
+func helm_driver(src *cluster.Cluster) string {
+	driver := src.GetHelm_driver()
+	if driver == "" {
+		driver = os.Getenv("HELM_DRIVER")
+	}
+	return strings.ToLower(driver)
+}
+
+// Open the PostgreSQL database of the Helm SQL storage, the connection string is read from the environment as Helm does.
+// The postgres database driver is registered by the Helm storage driver package.
+func open_helm_sql() (*sql.DB, error) {
+	db, err := sql.Open("postgres", os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// List the Helm release revisions of a namespace stored in the SQL storage
+func list_sql_releases(db *sql.DB, namespace string) ([]*helm.Release, error) {
+	rows, err := db.Query("SELECT body FROM "+helm_sql_table+" WHERE namespace = $1 AND owner = $2", namespace, helm_sql_owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*helm.Release
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return nil, err
+		}
+		rel, err := decode_helm_release(body)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, rel)
+	}
+	return releases, rows.Err()
+}
+
+// Decode a release body the way the Helm storage drivers encode it: JSON, gzipped unless stored by an old Helm, in base64
+func decode_helm_release(body string) (*helm.Release, error) {
+	b, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b, 0x08}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	var rel helm.Release
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
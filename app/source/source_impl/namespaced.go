@@ -0,0 +1,235 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package source_impl
+
+import (
+	"fmt"
+	"os"
+
+	app "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+// namespaced_kind is a kind read namespace by namespace: names are the RESOURCES entries selecting it, list reads one
+// page of a namespace as a list object, add keeps an object read and done runs once the kind is read
+type namespaced_kind struct {
+	kind     string
+	names    []string
+	plural   string // used in the error message
+	optional bool   // a kind that cannot be read is left out instead of stopping the scan
+	list     func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error)
+	add      func(resource *resource.Resources, obj runtime.Object)
+	done     func(src *cluster.Cluster, resource *resource.Resources)
+}
+
+// Namespaced kinds in the order their objects are added to the resources
+var namespaced_kinds = []namespaced_kind{
+	{
+		kind: "Job", names: []string{"jobs", "job"}, plural: "Jobs",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().BatchV1().Jobs(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.JobList = append(resource.JobList, *obj.(*batchv1.Job))
+		},
+	},
+	{
+		kind: "CronJob", names: []string{"cronjobs", "cronjob", "cj"}, plural: "CronJobs",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().BatchV1beta1().CronJobs(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.CronJobList = append(resource.CronJobList, *obj.(*batchv1beta1.CronJob))
+		},
+		done: func(src *cluster.Cluster, resource *resource.Resources) {
+			if src.Migrate_Images == "Yes" || src.Migrate_Images == "yes" {
+				for i := range resource.CronJobList {
+					migrate_images(src, resource.CronJobList[i].Spec.JobTemplate.Spec.Template.Spec.Containers)
+				}
+			}
+		},
+	},
+	{
+		kind: "Secret", names: []string{"secrets", "secret"}, plural: "Secrets",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().CoreV1().Secrets(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.SecretList = append(resource.SecretList, *obj.(*v1.Secret))
+		},
+		done: func(src *cluster.Cluster, resource *resource.Resources) {
+			// Remove default secret from each namespace
+			secrets := resource.SecretList[:0]
+			for _, item := range resource.SecretList {
+				if item.ObjectMeta.Annotations["kubernetes.io/service-account.name"] != "default" {
+					secrets = append(secrets, item)
+				}
+			}
+			resource.SecretList = secrets
+		},
+	},
+	{
+		kind: "ConfigMap", names: []string{"configmaps", "configmap", "cm"}, plural: "ConfigMaps",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().CoreV1().ConfigMaps(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.ConfigMapsList = append(resource.ConfigMapsList, *obj.(*v1.ConfigMap))
+		},
+	},
+	{
+		kind: "Ingress", names: []string{"ingresses", "ingress", "ing"}, plural: "Ingresses", optional: true,
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().NetworkingV1().Ingresses(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.IngressList = append(resource.IngressList, *obj.(*networking.Ingress))
+		},
+	},
+	{
+		kind: "PersistentVolumeClaim", names: []string{"persistentvolumeclaims", "persistentvolumeclaim", "pvc"}, plural: "Persistent Volume Claims",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().CoreV1().PersistentVolumeClaims(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.PersistentVolumeClaimsList = append(resource.PersistentVolumeClaimsList, *obj.(*v1.PersistentVolumeClaim))
+		},
+	},
+	{
+		kind: "Deployment", names: []string{"deployment", "deployments", "deploy"}, plural: "Deployments",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().AppsV1().Deployments(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.Depl = append(resource.Depl, *obj.(*app.Deployment))
+		},
+		done: func(src *cluster.Cluster, resource *resource.Resources) {
+			if src.Migrate_Images == "Yes" || src.Migrate_Images == "yes" {
+				for i := range resource.Depl {
+					migrate_images(src, resource.Depl[i].Spec.Template.Spec.Containers)
+				}
+			}
+		},
+	},
+	{
+		kind: "StatefulSet", names: []string{"statefulset", "statefulsets", "sts"}, plural: "StatefulSets",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().AppsV1().StatefulSets(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.StatefulSetList = append(resource.StatefulSetList, *obj.(*app.StatefulSet))
+		},
+		done: func(src *cluster.Cluster, resource *resource.Resources) {
+			if src.Migrate_Images == "Yes" || src.Migrate_Images == "yes" {
+				for i := range resource.StatefulSetList {
+					migrate_images(src, resource.StatefulSetList[i].Spec.Template.Spec.Containers)
+				}
+			}
+		},
+	},
+	{
+		kind: "Service", names: []string{"service", "svc"}, plural: "SVC",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().CoreV1().Services(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.Svcl = append(resource.Svcl, *obj.(*v1.Service))
+		},
+	},
+	{
+		kind: "DaemonSet", names: []string{"daemonset", "daemonsets", "ds"}, plural: "Daemonsets",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().AppsV1().DaemonSets(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.Dsl = append(resource.Dsl, *obj.(*app.DaemonSet))
+		},
+	},
+	{
+		kind: "HorizontalPodAutoscaler", names: []string{"horizontalpodautoscaler", "horizontalpodautoscalers", "hpa"}, plural: "hpas",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().AutoscalingV1().HorizontalPodAutoscalers(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.HpaList = append(resource.HpaList, *obj.(*autoscaling.HorizontalPodAutoscaler))
+		},
+	},
+	{
+		kind: "ServiceAccount", names: []string{"serviceaccount", "serviceaccounts", "sa"}, plural: "Service Accounts",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().CoreV1().ServiceAccounts(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.SvcAccList = append(resource.SvcAccList, *obj.(*v1.ServiceAccount))
+		},
+	},
+	{
+		kind: "Role", names: []string{"role", "roles"}, plural: "Role",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().RbacV1().Roles(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.RoleList = append(resource.RoleList, *obj.(*rbac.Role))
+		},
+	},
+	{
+		kind: "RoleBinding", names: []string{"rolebinding", "rolebindings"}, plural: "Role Bindings",
+		list: func(src *cluster.Cluster, namespace string, options metav1.ListOptions) (runtime.Object, error) {
+			return src.GetClientset().RbacV1().RoleBindings(namespace).List(src.GetCtx(), options)
+		},
+		add: func(resource *resource.Resources, obj runtime.Object) {
+			resource.RoleBindingList = append(resource.RoleBindingList, *obj.(*rbac.RoleBinding))
+		},
+	},
+}
+
+// Scan source kubernetes cluster and generate the objects of every namespaced kind selected by RESOURCES, every kind of
+// every namespace is listed through the same pool of workers
+func Generate_namespaced_configs(src *cluster.Cluster, resource *resource.Resources) {
+	var kinds []namespaced_kind
+	for _, k := range namespaced_kinds {
+		if itemExists(append([]string{"all"}, k.names...), src.GetResources()) {
+			kinds = append(kinds, k)
+		}
+	}
+
+	errs := scan_namespaced(src, resource.Nsl.Items, kinds, resource)
+	for i, k := range kinds {
+		if errs[i] != nil {
+			fmt.Printf("Could not read kubernetes %s using cluster client: %v\n", k.plural, errs[i])
+			if !k.optional {
+				os.Exit(1)
+			}
+			continue
+		}
+		if k.done != nil {
+			k.done(src, resource)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package source_impl
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+// Defaults used when the cluster does not set its own scan settings
+const (
+	default_scan_workers = 8
+	default_page_size    = 500
+)
+
+// list reads one page of a kind with the given options and returns the continue token and the number of objects read
+type list_page func(options metav1.ListOptions) (string, int, error)
+
+// List every page of a kind, the scope selectors and the page size are added to the options. An interrupted scan
+// stops here with a checkpoint instead of failing on the cancelled call, call it from the main goroutine only.
+func list_pages(src *cluster.Cluster, kind string, list list_page) (int, error) {
+	total, err := read_pages(src, kind, list)
+	if err != nil {
+		src.Exit_if_interrupted()
+	}
+	return total, err
+}
+
+// Read every page of a kind like list_pages, errors are returned as they are so the workers can use it
+func read_pages(src *cluster.Cluster, kind string, list list_page) (int, error) {
+	options := src.GetScope().List_options(kind)
+	options.Limit = int64(src.GetPage_size())
+	if options.Limit <= 0 {
+		options.Limit = default_page_size
+	}
+
+	total := 0
+	for {
+		next, count, err := list(options)
+		if err != nil {
+			return total, err
+		}
+		total += count
+		if next == "" {
+			return total, nil
+		}
+		options.Continue = next
+	}
+}
+
+// List the kinds in every namespace through one bounded pool of workers, a worker reads every page of one kind in one
+// namespace at a time. The objects are added from the calling goroutine, kind by kind in the order of kinds and in
+// namespace order within a kind. Returns the first error of each kind, an interrupted scan exits with a checkpoint
+// once the workers are done.
+func scan_namespaced(src *cluster.Cluster, namespaces []v1.Namespace, kinds []namespaced_kind, resource *resource.Resources) []error {
+	workers := src.GetWorkers()
+	if workers <= 0 {
+		workers = default_scan_workers
+	}
+
+	type call struct{ kind, namespace int }
+	// the pages of each kind and namespace are kept apart to keep the order
+	pages := make([][][]runtime.Object, len(kinds))
+	for k := range kinds {
+		pages[k] = make([][]runtime.Object, len(namespaces))
+	}
+	errs := make([]error, len(kinds))
+	total := len(kinds) * len(namespaces)
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		done    int
+		objects int
+		calls   = make(chan call)
+	)
+	for w := 0; w < workers && w < total; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range calls {
+				if src.GetCtx().Err() != nil {
+					continue
+				}
+				k := kinds[c.kind]
+				namespace := namespaces[c.namespace].ObjectMeta.Name
+				var items []runtime.Object
+				count, err := read_pages(src, k.kind, func(options metav1.ListOptions) (string, int, error) {
+					page, err := k.list(src, namespace, options)
+					if err != nil {
+						return "", 0, err
+					}
+					read, err := meta.ExtractList(page)
+					if err != nil {
+						return "", 0, err
+					}
+					accessor, err := meta.ListAccessor(page)
+					if err != nil {
+						return "", 0, err
+					}
+					items = append(items, read...)
+					return accessor.GetContinue(), len(read), nil
+				})
+
+				lock.Lock()
+				pages[c.kind][c.namespace] = items
+				if err != nil && errs[c.kind] == nil {
+					errs[c.kind] = fmt.Errorf("namespace %s: %v", namespace, err)
+				}
+				done++
+				objects += count
+				if total > 1 {
+					fmt.Printf("\rScanning %d kinds: %d/%d namespace lists, %d objects", len(kinds), done, total, objects)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for k := range kinds {
+		for i := range namespaces {
+			calls <- call{k, i}
+		}
+	}
+	close(calls)
+	wg.Wait()
+
+	if total > 1 {
+		fmt.Println()
+	}
+	src.Exit_if_interrupted()
+
+	for k, kind := range kinds {
+		if errs[k] != nil {
+			continue
+		}
+		for _, items := range pages[k] {
+			for _, obj := range items {
+				kind.add(resource, obj)
+			}
+		}
+	}
+	return errs
+}
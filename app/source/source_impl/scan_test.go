@@ -0,0 +1,73 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package source_impl
+
+import (
+	"reflect"
+	"testing"
+
+	app "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+func TestGenerateNamespacedConfigs(t *testing.T) {
+	namespaces := []string{"c", "a", "b"}
+	var objects []runtime.Object
+	for _, namespace := range namespaces {
+		objects = append(objects,
+			&app.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "web-" + namespace}},
+			&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "config-" + namespace}},
+		)
+	}
+	for _, workers := range []int{1, 4} {
+		src := &cluster.Cluster{}
+		src.SetClientset(fake.NewSimpleClientset(objects...))
+		src.SetWorkers(workers)
+		src.SetResources([]string{"deploy", "cm"})
+		resources := &resource.Resources{Nsl: &v1.NamespaceList{}}
+		for _, namespace := range namespaces {
+			resources.Nsl.Items = append(resources.Nsl.Items, v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+		}
+
+		Generate_namespaced_configs(src, resources)
+
+		var deployments, configMaps []string
+		for _, deployment := range resources.Depl {
+			deployments = append(deployments, deployment.Name)
+		}
+		for _, configMap := range resources.ConfigMapsList {
+			configMaps = append(configMaps, configMap.Name)
+		}
+		if want := []string{"web-c", "web-a", "web-b"}; !reflect.DeepEqual(deployments, want) {
+			t.Errorf("%d workers: deployments = %v, want %v", workers, deployments, want)
+		}
+		if want := []string{"config-c", "config-a", "config-b"}; !reflect.DeepEqual(configMaps, want) {
+			t.Errorf("%d workers: config maps = %v, want %v", workers, configMaps, want)
+		}
+		if len(resources.SecretList) != 0 {
+			t.Errorf("%d workers: %d secrets scanned, RESOURCES does not select them", workers, len(resources.SecretList))
+		}
+	}
+}
@@ -20,22 +20,24 @@
 package source_impl
 
 import (
-	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"strings"
 
-	"bytes"
-	"compress/gzip"
-	"encoding/base64"
 	"encoding/json"
-	"io"
 	"io/ioutil"
-	"log"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 
 	yaml "github.com/ghodss/yaml"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	helm "helm.sh/helm/v3/pkg/release"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
 	app "k8s.io/api/apps/v1"
 	autoscaling "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/api/core/v1"
@@ -60,9 +62,8 @@ import (
 
 var err error
 
-var ignore_ds = map[string][]string{"kube-system": {"fluentd-gke", "gke-metrics-agent", "gke-metrics-agent-windows", "kube-proxy", "metadata-proxy-v0.1", "nvidia-gpu-device-plugin", "prometheus-to-sd"}}
-var ignore_svc = map[string][]string{"kube-system": {"default-http-backend", "kube-dns", "metrics-server"}, "default": {"kubernetes"}}
-var ignore_dep = map[string][]string{"kube-system": {"event-exporter-gke", "fluentd-gke-scaler", "kube-dns", "kube-dns-autoscaler", "l7-default-backend", "metrics-server-v0.3.6", "stackdriver-metadata-agent-cluster-level"}}
+// Private ECR registry host, the first group is the AWS region
+var ecr_registry = regexp.MustCompile(`^[0-9]+\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com$`)
 
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
@@ -178,6 +179,18 @@ func Resource_trim_fields(resource_type string, resource *resource.Resources, re
 		resource.Depl = resource_list
 	}
 
+	if resource_type == "StatefulSet" && itemExists([]string{"statefulset", "statefulsets", "sts", "all"}, resToInclude) {
+		var resource_list []app.StatefulSet
+		for _, item := range resource.StatefulSetList {
+			Trim_Item(&item.ObjectMeta)
+			for i := range item.Spec.VolumeClaimTemplates {
+				item.Spec.VolumeClaimTemplates[i].Status = v1.PersistentVolumeClaimStatus{}
+			}
+			resource_list = append(resource_list, item)
+		}
+		resource.StatefulSetList = resource_list
+	}
+
 	if resource_type == "Secrets" && itemExists([]string{"secrets", "secret", "all"}, resToInclude) {
 		var resource_list []v1.Secret
 		for _, item := range resource.SecretList {
@@ -306,137 +319,40 @@ func Resource_trim_fields(resource_type string, resource *resource.Resources, re
 	}
 }
 
-// Scan source kubernetes cluster and generate the Job objects
-func Generate_job_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("jobs", src.GetResources()) || stringInSlice("job", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			job, err := src.GetClientset().BatchV1().Jobs(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Secrets using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to glabal services list
-			resource.JobList = append(resource.JobList, job.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the CronJob objects
-func Generate_cronjob_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("cronjobs", src.GetResources()) || stringInSlice("cronjob", src.GetResources()) || stringInSlice("cj", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			cronjob, err := src.GetClientset().BatchV1beta1().CronJobs(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Secrets using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-            if src.Migrate_Images == "Yes" || src.Migrate_Images == "yes" {
-                    for i, item := range cronjob.Items {
-                            for j, image_spec := range item.Spec.JobTemplate.Spec.Template.Spec.Containers {
-                                    image_name := image_spec.Image
-                                    updated_image := MIGRATE_IMAGES.Validate(image_name, src.Registry_Names)
-                                    if updated_image != "" {
-                                            cronjob.Items[i].Spec.JobTemplate.Spec.Template.Spec.Containers[j].Image = updated_image
-                                    }
-                            }
-                	}
-			}
-
-			// append list of services in this namespace to glabal services list
-			resource.CronJobList = append(resource.CronJobList, cronjob.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the secret objects
-func Generate_secret_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("secrets", src.GetResources()) || stringInSlice("secret", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			secret, err := src.GetClientset().CoreV1().Secrets(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Secrets using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Remove default secret from each namespace
-			for i, item := range secret.Items {
-				//	if strings.Contains(item.ObjectMeta.Name, "default-token-") {
-				if item.ObjectMeta.Annotations["kubernetes.io/service-account.name"] == "default" {
-					secret.Items = append(secret.Items[:i], secret.Items[i+1:]...)
-				}
-			}
-
-			// append list of services in this namespace to global services list
-			resource.SecretList = append(resource.SecretList, secret.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the ConfigMap objects
-func Generate_configmap_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("configmaps", src.GetResources()) || stringInSlice("configmap", src.GetResources()) || stringInSlice("cm", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			configmap, err := src.GetClientset().CoreV1().ConfigMaps(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes ConfigMaps using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to global services list
-			resource.ConfigMapsList = append(resource.ConfigMapsList, configmap.Items...)
-		}
-	}
-}
-
 // Scan source kubernetes cluster and generate the MutatingWebhookConfiguration objects
 func Generate_mutatingwebhook_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("mutatingWebhookconfigurations", src.GetResources()) || stringInSlice("mutatingwebhookconfiguration", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		mwc, err := src.GetClientset().AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "MutatingWebhookConfiguration", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().AdmissionregistrationV1().MutatingWebhookConfigurations().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.MutatingWebhookConfigurationList = append(resource.MutatingWebhookConfigurationList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
 			fmt.Printf("Could not read kubernetes MutatingWebhookConfiguration using cluster client: %v\n", err)
 			os.Exit(1)
 		}
-
-		// append list of services in this namespace to glabal services list
-		resource.MutatingWebhookConfigurationList = append(resource.MutatingWebhookConfigurationList, mwc.Items...)
 	}
 }
 
 // Scan source kubernetes cluster and generate the ValidtingWebhookConfiguration objects
 func Generate_validatingwebhook_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("validatingwebhookconfiguration", src.GetResources()) || stringInSlice("validatingwebhookconfigurations", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		vwc, err := src.GetClientset().AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			fmt.Printf("Could not read kubernetes MutatingWebhookConfiguration using cluster client: %v\n", err)
-			os.Exit(1)
-		}
-
-		// append list of services in this namespace to glabal services list
-		resource.ValidatingWebhookConfigurationList = append(resource.ValidatingWebhookConfigurationList, vwc.Items...)
-	}
-}
-
-// Scan source kubernetes cluster and generate the ConfigMap objects
-func Generate_ingress_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("ingresses", src.GetResources()) || stringInSlice("ingress", src.GetResources()) || stringInSlice("ing", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			ingress, err := src.GetClientset().NetworkingV1().Ingresses(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "ValidatingWebhookConfiguration", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().AdmissionregistrationV1().ValidatingWebhookConfigurations().List(src.GetCtx(), options)
 			if err != nil {
-				fmt.Printf("Could not read kubernetes Ingresses using cluster client: %v\n", err)
-				//os.Exit(1)
+				return "", 0, err
 			}
-
-			// append list of services in this namespace to global services list
-			resource.IngressList = append(resource.IngressList, ingress.Items...)
+			resource.ValidatingWebhookConfigurationList = append(resource.ValidatingWebhookConfigurationList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
+		if err != nil {
+			fmt.Printf("Could not read kubernetes ValidatingWebhookConfiguration using cluster client: %v\n", err)
+			os.Exit(1)
 		}
 	}
 }
@@ -444,211 +360,76 @@ func Generate_ingress_config(src *cluster.Cluster, resource *resource.Resources)
 // Scan source kubernetes cluster and generate the Storage Class objects
 func Generate_storage_class_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("storageclasses", src.GetResources()) || stringInSlice("storageclass", src.GetResources()) || stringInSlice("sc", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		//for _, element := range resource.Nsl.Items {
-		sc, err := src.GetClientset().StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "StorageClass", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().StorageV1().StorageClasses().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.StorageClassList = append(resource.StorageClassList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
 			fmt.Printf("Could not read kubernetes Storage Classes using cluster client: %v\n", err)
 			os.Exit(1)
 		}
-
-		// append list of services in this namespace to global services list
-		resource.StorageClassList = append(resource.StorageClassList, sc.Items...)
-		//}
-	}
-}
-
-// Scan source kubernetes cluster and generate the Persistent volume claim objects
-func Generate_pvc_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("persistentvolumeclaims", src.GetResources()) || stringInSlice("persistentvolumeclaim", src.GetResources()) || stringInSlice("pvc", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			pvc, err := src.GetClientset().CoreV1().PersistentVolumeClaims(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Storage Classes using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to glabal services list
-			resource.PersistentVolumeClaimsList = append(resource.PersistentVolumeClaimsList, pvc.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the service objects
-func Generate_deployment_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("deployment", src.GetResources()) || stringInSlice("deployments", src.GetResources()) || stringInSlice("deploy", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			dep, err := src.GetClientset().AppsV1().Deployments(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes SVC using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-            
-			if src.Migrate_Images == "Yes" || src.Migrate_Images == "yes" {
-                    for i, item := range dep.Items {
-                            for j, image_spec := range item.Spec.Template.Spec.Containers {
-                                    image_name := image_spec.Image
-                                    updated_image := MIGRATE_IMAGES.Validate(image_name, src.Registry_Names)
-                                    if updated_image != "" {
-                                            dep.Items[i].Spec.Template.Spec.Containers[j].Image = updated_image
-                                    }
-                            }
-                	}
-            }
-
-			// append list of services in this namespace to global services list
-			resource.Depl = append(resource.Depl, dep.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the service objects
-func Generate_service_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("service", src.GetResources()) || stringInSlice("svc", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of services
-		for _, element := range resource.Nsl.Items {
-			svc, err := src.GetClientset().CoreV1().Services(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes SVC using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to global services list
-			resource.Svcl = append(resource.Svcl, svc.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the daemonset objects
-func Generate_daemonset_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("daemonset", src.GetResources()) || stringInSlice("daemonsets", src.GetResources()) || stringInSlice("ds", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of daemonsets
-		for _, element := range resource.Nsl.Items {
-			ds, err := src.GetClientset().AppsV1().DaemonSets(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Daemonsets using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to global services list
-			resource.Dsl = append(resource.Dsl, ds.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the HPA objects
-func Generate_hpa_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("horizontalpodautoscaler", src.GetResources()) || stringInSlice("horizontalpodautoscalers", src.GetResources()) || stringInSlice("hpa", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of hpas
-		for _, element := range resource.Nsl.Items {
-			hpa, err := src.GetClientset().AutoscalingV1().HorizontalPodAutoscalers(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			fmt.Println()
-			if err != nil {
-				fmt.Printf("Could not read kubernetes hpas using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to glabal services list
-			resource.HpaList = append(resource.HpaList, hpa.Items...)
-		}
 	}
 }
 
+// Scan source kubernetes cluster and generate the pod security policy objects
 func Generate_psp_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("podsecuritypolicies", src.GetResources()) || stringInSlice("podsecuritypolicy", src.GetResources()) || stringInSlice("psp", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Get the list of pod security policies
-		psp, err := src.GetClientset().PolicyV1beta1().PodSecurityPolicies().List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "PodSecurityPolicy", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().PolicyV1beta1().PodSecurityPolicies().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.PspList = append(resource.PspList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
 			fmt.Printf("Could not read kubernetes pod security policies using cluster client: %v\n", err)
 			os.Exit(1)
 		}
-
-		// append list of pod security policies to glabal services list
-		resource.PspList = append(resource.PspList, psp.Items...)
-	}
-}
-
-func Generate_serviceaccount_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("serviceaccount", src.GetResources()) || stringInSlice("serviceaccounts", src.GetResources()) || stringInSlice("sa", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of daemonsets
-		for _, element := range resource.Nsl.Items {
-			sa, err := src.GetClientset().CoreV1().ServiceAccounts(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes hpas using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of service accounts in this namespace to glabal services list
-			resource.SvcAccList = append(resource.SvcAccList, sa.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the role objects
-func Generate_role_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("role", src.GetResources()) || stringInSlice("roles", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of daemonsets
-		for _, element := range resource.Nsl.Items {
-			rl, err := src.GetClientset().RbacV1().Roles(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Role using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to global services list
-			resource.RoleList = append(resource.RoleList, rl.Items...)
-		}
-	}
-}
-
-// Scan source kubernetes cluster and generate the role binding objects
-func Generate_role_binding_config(src *cluster.Cluster, resource *resource.Resources) {
-	if stringInSlice("rolebinding", src.GetResources()) || stringInSlice("rolebindings", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// Loop through all the namespaces and get the list of daemonsets
-		for _, element := range resource.Nsl.Items {
-			rbl, err := src.GetClientset().RbacV1().RoleBindings(element.ObjectMeta.Name).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Could not read kubernetes Role Bindings using cluster client: %v\n", err)
-				os.Exit(1)
-			}
-
-			// append list of services in this namespace to global services list
-			resource.RoleBindingList = append(resource.RoleBindingList, rbl.Items...)
-		}
 	}
 }
 
 // Scan source kubernetes cluster and generate the cluster role objects
 func Generate_cluster_role_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("clusterrole", src.GetResources()) || stringInSlice("clusterroles", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// not a namespaced resource and hence no loop through all the namespaces and get the list of clusterroles
-		crl, err := src.GetClientset().RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "ClusterRole", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().RbacV1().ClusterRoles().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.ClusterRoleList = append(resource.ClusterRoleList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
-			fmt.Printf("Could not read kubernetes Role Bindings using cluster client: %v\n", err)
+			fmt.Printf("Could not read kubernetes Cluster Roles using cluster client: %v\n", err)
 			os.Exit(1)
 		}
-
-		// append list of services in this namespace to global services list
-		resource.ClusterRoleList = append(resource.ClusterRoleList, crl.Items...)
-		// }
 	}
 }
 
-// Scan source kubernetes cluster and generate the cluster role objects
+// Scan source kubernetes cluster and generate the cluster role binding objects
 func Generate_cluster_role_binding_config(src *cluster.Cluster, resource *resource.Resources) {
 	if stringInSlice("clusterrolebinding", src.GetResources()) || stringInSlice("clusterrolebindings", src.GetResources()) || stringInSlice("all", src.GetResources()) {
-		// not a namespaced resource and hence no loop through all the namespaces and get the list of clusterrole bindings
-		crbl, err := src.GetClientset().RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+		// not a namespaced resource, list it page by page
+		_, err := list_pages(src, "ClusterRoleBinding", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().RbacV1().ClusterRoleBindings().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.ClusterRoleBindingList = append(resource.ClusterRoleBindingList, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
-			fmt.Printf("Could not read kubernetes Role Bindings using cluster client: %v\n", err)
+			fmt.Printf("Could not read kubernetes Cluster Role Bindings using cluster client: %v\n", err)
 			os.Exit(1)
 		}
-
-		// append list of services in this namespace to global services list
-		resource.ClusterRoleBindingList = append(resource.ClusterRoleBindingList, crbl.Items...)
-		// }
 	}
 }
 
@@ -660,11 +441,13 @@ func Generate_namespace_list(src *cluster.Cluster, resource *resource.Resources)
 
 		//Loop through the list of namespace name entered. by used and get the namesapce object from cluster
 		for _, element := range src.GetNamespaces() {
-			if element == "kube-system" || element == "kube-public" || element == "kube-node-lease" {
+			if src.GetIgnore().Skip_namespace(element) {
+				fmt.Println("Namespace", element, "holds system components, skipped")
 				continue
 			}
-			ns, err := src.GetClientset().CoreV1().Namespaces().Get(context.TODO(), element, metav1.GetOptions{})
+			ns, err := src.GetClientset().CoreV1().Namespaces().Get(src.GetCtx(), element, metav1.GetOptions{})
 			if err != nil {
+				src.Exit_if_interrupted()
 				fmt.Printf("Could not List kubernetes namespaces using cluster client: %v\n", err)
 				os.Exit(1)
 			}
@@ -673,7 +456,15 @@ func Generate_namespace_list(src *cluster.Cluster, resource *resource.Resources)
 		}
 	} else {
 		fmt.Println("Namespace list entered as 'all' by user, hence all namespaces will be considered")
-		resource.Nsl, err = src.GetClientset().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+		resource.Nsl = new(v1.NamespaceList)
+		_, err = list_pages(src, "Namespace", func(options metav1.ListOptions) (string, int, error) {
+			list, err := src.GetClientset().CoreV1().Namespaces().List(src.GetCtx(), options)
+			if err != nil {
+				return "", 0, err
+			}
+			resource.Nsl.Items = append(resource.Nsl.Items, list.Items...)
+			return list.Continue, len(list.Items), nil
+		})
 		if err != nil {
 			fmt.Printf("Could not List kubernetes namespaces using cluster client: %v\n", err)
 			os.Exit(1)
@@ -681,7 +472,7 @@ func Generate_namespace_list(src *cluster.Cluster, resource *resource.Resources)
 
 		j := 0
 		for _, element := range resource.Nsl.Items {
-			if element.ObjectMeta.Name != "kube-system" && element.ObjectMeta.Name != "kube-public" && element.ObjectMeta.Name != "kube-node-lease" {
+			if !src.GetIgnore().Skip_namespace(element.ObjectMeta.Name) {
 				resource.Nsl.Items[j] = element
 				j++
 			}
@@ -689,151 +480,371 @@ func Generate_namespace_list(src *cluster.Cluster, resource *resource.Resources)
 		resource.Nsl.Items = resource.Nsl.Items[:j]
 	}
 
+	// namespaces opted out with kmf.io/skip or out of the name patterns
+	j := 0
+	for _, element := range resource.Nsl.Items {
+		if keep, reason := src.GetScope().Keep("Namespace", &element); !keep {
+			fmt.Println("Namespace", element.ObjectMeta.Name, reason+", skipped")
+			continue
+		}
+		resource.Nsl.Items[j] = element
+		j++
+	}
+	resource.Nsl.Items = resource.Nsl.Items[:j]
 }
 
 //Scan source kubernetes cluster and generate the Helm charts
 func Generate_helm_charts(src *cluster.Cluster, resource *resource.Resources) {
-	labelSelector := fmt.Sprintf("owner=helm")
-	listOptions := metav1.ListOptions{
-		LabelSelector: labelSelector,
+	resource.HelmList = make(map[string]map[string]string)
+
+	// The SQL storage holds the releases of every namespace, it is opened once for the whole scan
+	var db *sql.DB
+	if helm_driver(src) == "sql" {
+		var err error
+		db, err = open_helm_sql()
+		if err != nil {
+			fmt.Printf("Could not open the Helm SQL storage: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
 	}
 
-	resource.HelmList = make(map[string]map[string]string)
 	for _, element := range resource.Nsl.Items {
+		src.Exit_if_interrupted()
 		var helmCharts = make(map[string]helm.Release)
 
-		secretList, err := src.GetClientset().CoreV1().Secrets(element.ObjectMeta.Name).List(context.TODO(), listOptions)
+		releases, err := list_helm_releases(src, db, element.ObjectMeta.Name)
 		if err != nil {
-			fmt.Printf("Could not read kubernetes Secrets using cluster client: %v\n", err)
+			fmt.Printf("Could not read Helm releases using cluster client: %v\n", err)
 			os.Exit(1)
 		}
 
-		for _, secret := range secretList.Items {
-			status_tmp := secret.ObjectMeta.Labels["status"]
-			status := strings.Split(status_tmp, ":")
-			if status[0] == "deployed" {
+		for name, revisions := range group_helm_releases(releases) {
+			latest := revisions[0]
+			deployed := latest_deployed_release(revisions)
 
-				base64Text := make([]byte, base64.StdEncoding.DecodedLen(len(secret.Data["release"])))
-				base64.StdEncoding.Decode(base64Text, []byte(secret.Data["release"]))
+			if latest.Info == nil || latest.Info.Status != helm.StatusDeployed {
+				status := helm.StatusUnknown
+				if latest.Info != nil {
+					status = latest.Info.Status
+				}
+				if deployed != nil {
+					message := fmt.Sprintf("latest revision %d is %s, migrating deployed revision %d instead", latest.Version, status, deployed.Version)
+					fmt.Println("Helm release", name, "in namespace", element.ObjectMeta.Name, ":", message)
+					resource.Report.Add("helm", "HelmRelease", element.ObjectMeta.Name, name, message)
+				} else {
+					message := fmt.Sprintf("latest revision %d is %s and there is no deployed revision, skipping", latest.Version, status)
+					fmt.Println("Helm release", name, "in namespace", element.ObjectMeta.Name, ":", message)
+					resource.Report.Add("helm", "HelmRelease", element.ObjectMeta.Name, name, message)
+					continue
+				}
+			}
 
-				var secret_uncompressed bytes.Buffer
+			//Add the chart name to the helmCharts global variable
+			helmCharts[name] = *deployed
+		}
 
-				err = gunzipWrite(&secret_uncompressed, &base64Text)
-				if err != nil {
-					fmt.Println("test9")
-					log.Fatal(err)
-				}
-				secret_string := secret_uncompressed.String()
+		if len(helmCharts) > 0 {
+			path := src.Helm_path + "/KMFHelmCharts/namespaces/" + element.ObjectMeta.Name
+			writeChartToFile(helmCharts, path, element.ObjectMeta.Name, resource)
+		}
+	}
+}
 
-				//convert the release string into helm release struct
-				var secret_data helm.Release //map[string]interface{}
-				json.Unmarshal([]byte(secret_string), &secret_data)
+// List all the Helm release revisions stored in a namespace using the storage driver configured for the cluster.
+// When no driver is configured both Secrets and ConfigMaps are scanned, the SQL storage is read through db.
+func list_helm_releases(src *cluster.Cluster, db *sql.DB, namespace string) ([]*helm.Release, error) {
+	var drivers []helmdriver.Driver
+	switch helm_driver(src) {
+	case "secret", "secrets":
+		drivers = append(drivers, helmdriver.NewSecrets(src.GetClientset().CoreV1().Secrets(namespace)))
+	case "configmap", "configmaps":
+		drivers = append(drivers, helmdriver.NewConfigMaps(src.GetClientset().CoreV1().ConfigMaps(namespace)))
+	case "sql":
+		return list_sql_releases(db, namespace)
+	case "", "auto":
+		drivers = append(drivers, helmdriver.NewSecrets(src.GetClientset().CoreV1().Secrets(namespace)))
+		drivers = append(drivers, helmdriver.NewConfigMaps(src.GetClientset().CoreV1().ConfigMaps(namespace)))
+	default:
+		return nil, fmt.Errorf("unsupported Helm storage driver %q, accepted values are secret, configmap or sql", helm_driver(src))
+	}
+
+	var releases []*helm.Release
+	for _, d := range drivers {
+		list, err := d.List(func(*helm.Release) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, list...)
+	}
+	return releases, nil
+}
 
-				//Add the chart name to the helmCharts global variable
-				helmCharts[secret_data.Name] = secret_data
-				path := src.Helm_path + "/KMFHelmCharts/namespaces/" + element.ObjectMeta.Name
-				writeChartToFile(helmCharts, path, element.ObjectMeta.Name, resource)
-			}
+// Group release revisions by release name, each group is sorted from the newest to the oldest revision
+func group_helm_releases(releases []*helm.Release) map[string][]*helm.Release {
+	grouped := make(map[string][]*helm.Release)
+	for _, rel := range releases {
+		grouped[rel.Name] = append(grouped[rel.Name], rel)
+	}
+	for _, revisions := range grouped {
+		sort.Slice(revisions, func(i, j int) bool { return revisions[i].Version > revisions[j].Version })
+	}
+	return grouped
+}
 
+// Return the newest deployed revision of a release or nil when no revision is deployed
+func latest_deployed_release(revisions []*helm.Release) *helm.Release {
+	for _, rel := range revisions {
+		if rel.Info != nil && rel.Info.Status == helm.StatusDeployed {
+			return rel
 		}
 	}
+	return nil
 }
 
 func writeChartToFile(charts map[string]helm.Release, path string, namespace string, resource *resource.Resources) {
-	// Create the directory locally to store the helm charts
+	// Create the directory tree locally to store the helm charts
 	fmt.Println("Path :", path)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		err = os.Mkdir(path, 0700)
-		if err != nil {
-			fmt.Println("Error creating the path for helm charts\n", err)
-		}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		fmt.Println("Error creating the path for helm charts\n", err)
+		resource.Report.Add("helm", "HelmRelease", namespace, "", fmt.Sprintf("could not create chart directory %s: %v", path, err))
+		return
 	}
 
 	var chartsPath = make(map[string]string)
-	//var helmCharts = make(map[string]helm.Release)
-	// Get the ctarts from release struct
+	// Get the charts from release struct
 	for k, v := range charts {
+		fmt.Println("Chart Name:", k)
 
-		// Create subdirectory to store the charts for this release
-		if _, err := os.Stat(path + "/" + v.Name); os.IsNotExist(err) {
-			err = os.Mkdir(path+"/"+v.Name, 0700)
-			if err != nil {
-				fmt.Println("Error creating the path for helm release: \n", err, v.Name)
-			}
+		releasePath, err := safe_join(path, v.Name)
+		if err == nil {
+			err = write_release_chart(v, path, releasePath)
+		}
+		if err != nil {
+			fmt.Println("Error writing the chart for helm release: \n", err, v.Name)
+			resource.Report.Add("helm", "HelmRelease", namespace, v.Name, fmt.Sprintf("could not write chart: %v", err))
+			continue
 		}
 
 		// Add path to the chart to the HelmList to later install the chart from this path on EKS cluster
-		chartsPath[v.Name] = path + "/" + v.Name
+		chartsPath[v.Name] = releasePath
+	}
 
-		helm_templates := v.Chart.Templates
-		fmt.Println("Chart Name:", k)
-		//fmt.Println("template:", secret.Data["release"])
-		for _, element := range helm_templates {
+	resource.HelmList[namespace] = chartsPath
+}
 
-			fmt.Println("secrets:", element.Name)
+// Write the chart of a release to a temporary directory next to releasePath and move it in place once complete,
+// so an interrupted or failed extraction never leaves a partial chart behind
+func write_release_chart(v helm.Release, path string, releasePath string) error {
+	if v.Chart == nil {
+		return fmt.Errorf("release has no chart")
+	}
 
-			//fmt.Println("template:", string(element.Data))
-			if _, err := os.Stat(filepath.Dir(path + "/" + v.Name + "/" + element.Name)); os.IsNotExist(err) {
-				os.MkdirAll(filepath.Dir(path+"/"+v.Name+"/"+element.Name), 0700)
-			}
-			err := ioutil.WriteFile(path+"/"+v.Name+"/"+element.Name, element.Data, 0600)
+	tmpPath, err := ioutil.TempDir(path, "."+filepath.Base(releasePath)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	for _, element := range v.Chart.Templates {
+		fmt.Println("secrets:", element.Name)
+		if err := write_chart_file(tmpPath, element.Name, element.Data); err != nil {
+			return err
+		}
+	}
+
+	for _, element := range v.Chart.Files {
+		fmt.Println("Files Name:", element.Name)
+		if err := write_chart_file(tmpPath, element.Name, element.Data); err != nil {
+			return err
+		}
+	}
+
+	//Write values file
+	jsonString, err := json.Marshal(v.Chart.Values)
+	if err != nil {
+		return err
+	}
+	valuesyaml, err := yaml.JSONToYAML(jsonString)
+	if err != nil {
+		return err
+	}
+	if err := write_chart_file(tmpPath, "values.yaml", valuesyaml); err != nil {
+		return err
+	}
+
+	//Write Chart metadata to Chart.yaml file
+	jsonString, err = json.Marshal(v.Chart.Metadata)
+	if err != nil {
+		return err
+	}
+	chartyaml, err := yaml.JSONToYAML(jsonString)
+	if err != nil {
+		return err
+	}
+	if err := write_chart_file(tmpPath, "Chart.yaml", chartyaml); err != nil {
+		return err
+	}
+
+	// Replace the chart extracted by a previous run
+	if err := os.RemoveAll(releasePath); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, releasePath)
+}
+
+// Write a chart file below root, creating the parent directories as needed
+func write_chart_file(root string, name string, data []byte) error {
+	filePath, err := safe_join(root, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, data, 0600)
+}
+
+// Join a relative name taken from release data to root, rejecting absolute names and names escaping root
+func safe_join(root string, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path %q in chart", name)
+	}
+	return filepath.Join(root, cleaned), nil
+}
+
+// Package the extracted Helm charts as versioned .tgz archives and push them to an OCI registry when one is configured
+func Package_helm_charts(src *cluster.Cluster, resource *resource.Resources) {
+	if src.GetHelm_package() != "Yes" && src.GetHelm_package() != "yes" {
+		return
+	}
+
+	for namespace, charts := range resource.HelmList {
+		packagePath := src.Helm_path + "/KMFHelmCharts/packages/" + namespace
+		if err := os.MkdirAll(packagePath, 0700); err != nil {
+			fmt.Println("Error creating the path for helm packages\n", err)
+			resource.Report.Add("helm", "HelmRelease", namespace, "", fmt.Sprintf("could not create package directory: %v", err))
+			continue
+		}
+
+		for release, chartPath := range charts {
+			fmt.Println("Packaging chart for Helm release", release, "in namespace", namespace)
+			ch, archive, err := package_helm_chart(chartPath, packagePath)
 			if err != nil {
-				panic(err)
+				fmt.Println("Error packaging Helm chart for release", release, ":", err)
+				resource.Report.Add("helm", "HelmRelease", namespace, release, fmt.Sprintf("could not package chart: %v", err))
+				continue
 			}
-		}
+			fmt.Println("Packaged chart:", archive)
 
-		helm_files := v.Chart.Files
-		for _, element := range helm_files {
-			fmt.Println("Files Name:", element.Name)
-			//fmt.Println("template:", string(element.Data))
-			if _, err := os.Stat(filepath.Dir(path + "/" + v.Name + "/" + element.Name)); os.IsNotExist(err) {
-				os.MkdirAll(filepath.Dir(path+"/"+v.Name+"/"+element.Name), 0700)
+			if src.GetHelm_registry() == "" {
+				continue
 			}
-			err := ioutil.WriteFile(path+"/"+v.Name+"/"+element.Name, element.Data, 0600)
+			ref, err := push_helm_chart(src, ch, archive, namespace)
 			if err != nil {
-				panic(err)
+				fmt.Println("Error pushing Helm chart for release", release, ":", err)
+				resource.Report.Add("helm", "HelmRelease", namespace, release, fmt.Sprintf("could not push chart %s: %v", archive, err))
+				continue
 			}
+			fmt.Println("Pushed chart:", ref)
 		}
+	}
+}
 
-		//Write values file
-		if _, err := os.Stat(filepath.Dir(path + "/" + v.Name + "/" + "values.json")); os.IsNotExist(err) {
-			os.MkdirAll(filepath.Dir(path+"/"+v.Name+"/"+"values.json"), 0700)
-		}
+// Load an extracted chart directory, resolve its dependencies and save it as <name>-<version>.tgz in packagePath
+func package_helm_chart(chartPath string, packagePath string) (*chart.Chart, string, error) {
+	ch, err := loader.LoadDir(chartPath)
+	if err != nil {
+		return nil, "", err
+	}
 
-		jsonString, err := json.Marshal(v.Chart.Values)
-		valuesyaml, err := yaml.JSONToYAML(jsonString)
-		err = ioutil.WriteFile(path+"/"+v.Name+"/"+"values.yaml", valuesyaml, 0600)
-		if err != nil {
-			panic(err)
+	// Charts recovered from a release only carry their dependencies in the metadata, apiVersion v1 charts
+	// keep them in requirements.yaml so the Chart.yaml is upgraded to v2 for helm to honour them
+	if len(ch.Metadata.Dependencies) > 0 {
+		if ch.Metadata.APIVersion != chart.APIVersionV2 {
+			ch.Metadata.APIVersion = chart.APIVersionV2
+			jsonString, err := json.Marshal(ch.Metadata)
+			if err != nil {
+				return nil, "", err
+			}
+			chartyaml, err := yaml.JSONToYAML(jsonString)
+			if err != nil {
+				return nil, "", err
+			}
+			if err := ioutil.WriteFile(filepath.Join(chartPath, "Chart.yaml"), chartyaml, 0600); err != nil {
+				return nil, "", err
+			}
 		}
 
-		//Write Chart metadata to chart.json file
-		if _, err := os.Stat(filepath.Dir(path + "/" + v.Name + "/" + "Chart.yaml")); os.IsNotExist(err) {
-			os.MkdirAll(filepath.Dir(path+"/"+v.Name+"/"+"Chart.yaml"), 0700)
+		if len(ch.Dependencies()) < len(ch.Metadata.Dependencies) {
+			cmd := exec.Command("helm", "dependency", "build")
+			cmd.Dir = chartPath
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				return nil, "", fmt.Errorf("helm dependency build failed: %v: %s", err, out)
+			}
+			if ch, err = loader.LoadDir(chartPath); err != nil {
+				return nil, "", err
+			}
 		}
+	}
 
-		jsonString, err = json.Marshal(v.Chart.Metadata)
-		chartyaml, err := yaml.JSONToYAML(jsonString)
-		err = ioutil.WriteFile(path+"/"+v.Name+"/"+"Chart.yaml", chartyaml, 0600)
-		if err != nil {
-			panic(err)
-		}
+	if err := ch.Validate(); err != nil {
+		return nil, "", err
 	}
 
-	resource.HelmList[namespace] = chartsPath
+	archive, err := chartutil.Save(ch, packagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	return ch, archive, nil
 }
 
-func gunzipWrite(w io.Writer, data *[]byte) error {
-	// Write gzipped data to the client
+// Push a packaged chart to <registry>/<namespace>/<chart name>:<chart version> using the helm CLI
+func push_helm_chart(src *cluster.Cluster, ch *chart.Chart, archive string, namespace string) (string, error) {
+	registry := strings.TrimSuffix(strings.TrimPrefix(src.GetHelm_registry(), "oci://"), "/")
+	repository := registry + "/" + namespace
+
+	// ECR only accepts pushes to repositories that already exist
+	if host := strings.SplitN(registry, "/", 2); ecr_registry.MatchString(host[0]) {
+		region := ecr_registry.FindStringSubmatch(host[0])[1]
+		repo_name := namespace + "/" + ch.Name()
+		if len(host) == 2 {
+			repo_name = host[1] + "/" + repo_name
+		}
+		if MIGRATE_IMAGES.Ensure_ecr_repo(repo_name, region) == "" {
+			return "", fmt.Errorf("could not create ECR repository %s", repo_name)
+		}
+	}
 
-	gr, err := gzip.NewReader(bytes.NewBuffer(*data))
+	args := []string{"push", archive, "oci://" + repository}
+	if src.GetHelm_plain_http() == "Yes" || src.GetHelm_plain_http() == "yes" {
+		args = append(args, "--plain-http")
+	}
+	cmd := exec.Command("helm", args...)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return "", fmt.Errorf("%v: %s", err, out)
 	}
-	defer gr.Close()
-	*data, err = ioutil.ReadAll(gr)
+	return repository + "/" + ch.Name() + ":" + ch.Metadata.Version, nil
+}
 
-	w.Write(*data)
-	return nil
+// Copy the images of the containers from the 3rd party registries to ECR. An image copied by the run being resumed
+// is not pulled and pushed again.
+func migrate_images(src *cluster.Cluster, containers []v1.Container) {
+	for j, image_spec := range containers {
+		updated_image, done := src.GetCheckpoint().Image(image_spec.Image)
+		if !done {
+			updated_image = MIGRATE_IMAGES.Validate(image_spec.Image, src.Registry_Names)
+			src.GetCheckpoint().Set_image(image_spec.Image, updated_image)
+			// a copy takes long enough that it is worth keeping right away
+			if updated_image != "" {
+				src.GetCheckpoint().Flush()
+			}
+		}
+		if updated_image != "" {
+			containers[j].Image = updated_image
+		}
+	}
 }
@@ -0,0 +1,64 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package source_impl
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.FromSlash("/tmp/KMFHelmCharts/namespaces/default/app")
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"chart file", "Chart.yaml", "Chart.yaml", false},
+		{"template", "templates/deployment.yaml", "templates/deployment.yaml", false},
+		{"dependency", "charts/redis/values.yaml", "charts/redis/values.yaml", false},
+		{"cleaned inside", "templates/../values.yaml", "values.yaml", false},
+		{"dot prefix", "./templates/service.yaml", "templates/service.yaml", false},
+		{"dots in name", "..values.yaml", "..values.yaml", false},
+		{"empty", "", "", true},
+		{"dot", ".", "", true},
+		{"parent", "..", "", true},
+		{"escapes root", "../../etc/passwd", "", true},
+		{"escapes after clean", "templates/../../secret", "", true},
+		{"absolute", "/etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := safe_join(root, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("safe_join(%q) = %q, want an error", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("safe_join(%q) returned %v", tt.path, err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("safe_join(%q) = %q, want %q", tt.path, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package velero
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Export writes the scanned objects as a Velero backup archive, so they can be restored with velero or read back with the VELERO source
+func Export(resources *resource.Resources, archivePath string) error {
+	fmt.Println("Exporting resources....start")
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+
+	now := time.Now()
+	write := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := archive.Write(data)
+		return err
+	}
+
+	if err := write("metadata/version", []byte("1\n")); err != nil {
+		return err
+	}
+
+	count := 0
+	var failed error
+	resources.Each(func(kindName string, obj resource.Object) {
+		if failed != nil {
+			return
+		}
+		kind, ok := resource.Find_kind(kindName)
+		if !ok {
+			return
+		}
+		// objects listed through the typed clients have no apiVersion and kind
+		obj.GetObjectKind().SetGroupVersionKind(kind.GroupVersionKind())
+		data, err := json.Marshal(obj)
+		if err != nil {
+			failed = fmt.Errorf("%s %s: %v", kindName, obj.GetName(), err)
+			return
+		}
+
+		name := filepath.ToSlash(filepath.Join("resources", resource_dir(kind), "cluster", obj.GetName()+".json"))
+		if kind.Namespaced {
+			name = filepath.ToSlash(filepath.Join("resources", resource_dir(kind), "namespaces", obj.GetNamespace(), obj.GetName()+".json"))
+		}
+		if err := write(name, data); err != nil {
+			failed = err
+			return
+		}
+		count++
+	})
+	if failed != nil {
+		return failed
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d objects to %s\n", count, archivePath)
+	fmt.Println("Exporting resources....End")
+	return nil
+}
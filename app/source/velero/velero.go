@@ -0,0 +1,249 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package velero
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	cluster "containers-migration-factory/app/cluster"
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+	source_impl "containers-migration-factory/app/source/source_impl"
+)
+
+// VELERO reads the objects of a Velero backup archive instead of a live cluster
+type VELERO struct {
+	refused []report.Entry // objects of the backup KMF cannot read, reported with the scan
+}
+
+// suffix of the directory holding the preferred API version when the backup was taken with EnableAPIGroupVersions
+const preferred_version_suffix = "-preferredversion"
+
+// Load the backup archive and serve its objects through an in-memory clientset so the generators work unchanged
+func (v *VELERO) Connect(sCluster *cluster.Cluster) {
+	objects, refused, err := read_backup(sCluster.GetVelero_backup())
+	if err != nil {
+		fmt.Printf("The Velero backup %s cannot be loaded: %v\n", sCluster.GetVelero_backup(), err)
+		os.Exit(1)
+	}
+	fmt.Printf("Loaded %d objects from the Velero backup %s\n", len(objects), sCluster.GetVelero_backup())
+	v.refused = refused
+	sCluster.SetClientset(fake.NewSimpleClientset(objects...))
+}
+
+func (v *VELERO) GetSourceDetails(sCluster *cluster.Cluster) resource.Resources {
+	fmt.Println("VELERO GetSourceDetails....")
+	resources := resource.Resources{Report: report.New()}
+	for _, entry := range v.refused {
+		resources.Report.Add(entry.Stage, entry.Kind, entry.Namespace, entry.Name, entry.Message)
+	}
+	source_impl.Generate_namespace_list(sCluster, &resources)
+
+	source_impl.Generate_helm_charts(sCluster, &resources)
+	source_impl.Package_helm_charts(sCluster, &resources)
+
+	source_impl.Generate_namespaced_configs(sCluster, &resources)
+	source_impl.Generate_mutatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_validatingwebhook_config(sCluster, &resources)
+	source_impl.Generate_storage_class_config(sCluster, &resources)
+	source_impl.Generate_psp_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_config(sCluster, &resources)
+	source_impl.Generate_cluster_role_binding_config(sCluster, &resources)
+
+	return resources
+}
+
+func (v VELERO) FormatSourceData(resource *resource.Resources, resToInclude []string) {
+	fmt.Println("VELERO FormatSourceData....start")
+	source_impl.Resource_trim_fields("Namespace", resource, resToInclude)
+	source_impl.Resource_trim_fields("DaemonSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("MutatingWebhookConfiguration", resource, resToInclude)
+	source_impl.Resource_trim_fields("Deployment", resource, resToInclude)
+	source_impl.Resource_trim_fields("StatefulSet", resource, resToInclude)
+	source_impl.Resource_trim_fields("Service", resource, resToInclude)
+	source_impl.Resource_trim_fields("Secrets", resource, resToInclude)
+	source_impl.Resource_trim_fields("StorageClasses", resource, resToInclude)
+	source_impl.Resource_trim_fields("Roles", resource, resToInclude)
+	source_impl.Resource_trim_fields("RoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoles", resource, resToInclude)
+	source_impl.Resource_trim_fields("ClusterRoleBindings", resource, resToInclude)
+	source_impl.Resource_trim_fields("HorizontalPodAutoscaler", resource, resToInclude)
+	source_impl.Resource_trim_fields("PodSecurityPolicy", resource, resToInclude)
+	source_impl.Resource_trim_fields("ServiceAccount", resource, resToInclude)
+	source_impl.Resource_trim_fields("PersistentVolumeClaim", resource, resToInclude)
+	source_impl.Resource_trim_fields("CronJob", resource, resToInclude)
+	source_impl.Resource_trim_fields("Job", resource, resToInclude)
+	source_impl.Resource_trim_fields("ConfigMap", resource, resToInclude)
+	source_impl.Resource_trim_fields("Ingress", resource, resToInclude)
+	fmt.Println("VELERO FormatSourceData....End")
+}
+
+// Directory of a kind in the backup layout, <resource>.<group> or <resource> for the core group
+func resource_dir(kind resource.Kind) string {
+	if kind.Group == "" {
+		return kind.Resource
+	}
+	return kind.Resource + "." + kind.Group
+}
+
+// Read the objects of the kinds handled by KMF from a Velero backup archive laid out as
+// resources/<resource>.<group>[/<version>[-preferredversion]]/{namespaces/<namespace>,cluster}/<name>.json
+// Objects stored in another version than the one KMF works with are refused and returned as report entries.
+func read_backup(backup string) ([]runtime.Object, []report.Entry, error) {
+	file, err := os.Open(backup)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	kinds := make(map[string]resource.Kind)
+	for _, kind := range resource.Kinds {
+		kinds[resource_dir(kind)] = kind
+	}
+
+	// the same object is stored with and without the version directories, the version KMF works with wins over
+	// the plain layout, which wins over the preferred version directory
+	type entry struct {
+		kind resource.Kind
+		data []byte
+		rank int
+	}
+	entries := make(map[string]entry)
+	skipped := make(map[string]int)
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(name, "resources/") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		parts := strings.Split(name, "/")
+		kind, ok := kinds[parts[1]]
+		if !ok {
+			skipped[parts[1]]++
+			continue
+		}
+		rest, rank := parts[2:], 1
+		if len(rest) > 0 && rest[0] != "namespaces" && rest[0] != "cluster" {
+			// a version directory, only the version KMF works with and the preferred version are read
+			switch version := strings.TrimSuffix(rest[0], preferred_version_suffix); {
+			case version == kind.Version:
+				rank = 2
+			case version != rest[0]:
+				rank = 0
+			default:
+				continue
+			}
+			rest = rest[1:]
+		}
+		if !(len(rest) == 3 && rest[0] == "namespaces") && !(len(rest) == 2 && rest[0] == "cluster") {
+			continue
+		}
+
+		key := parts[1] + "/" + strings.Join(rest, "/")
+		if existing, ok := entries[key]; ok && existing.rank >= rank {
+			continue
+		}
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", name, err)
+		}
+		entries[key] = entry{kind: kind, data: data, rank: rank}
+	}
+
+	if len(skipped) > 0 {
+		var dirs []string
+		for dir, count := range skipped {
+			dirs = append(dirs, fmt.Sprintf("%s (%d)", dir, count))
+		}
+		sort.Strings(dirs)
+		fmt.Println("Skipping backup resources not handled by KMF:", strings.Join(dirs, ", "))
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var objects []runtime.Object
+	var refused []report.Entry
+	for _, key := range keys {
+		kind := entries[key].kind
+		obj, err := decode(kind, entries[key].data)
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", key, err)
+			// <group>/namespaces/<namespace>/<name>.json or <group>/cluster/<name>.json
+			rest := strings.Split(key, "/")[1:]
+			namespace, name := "", strings.TrimSuffix(rest[len(rest)-1], ".json")
+			if rest[0] == "namespaces" {
+				namespace = rest[1]
+			}
+			refused = append(refused, report.Entry{Stage: "velero", Kind: kind.Kind, Namespace: namespace, Name: name, Message: fmt.Sprintf("not migrated: %v", err)})
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, refused, nil
+}
+
+// Decode an object of the backup, it must be stored in the version KMF works with. Fields move or change shape between
+// versions, e.g. the metrics of an autoscaling/v2 HorizontalPodAutoscaler or the backend of a networking.k8s.io/v1beta1
+// Ingress, and the conversions are only known to the API server, so other versions are refused rather than decoded
+// with their fields lost.
+func decode(kind resource.Kind, data []byte) (runtime.Object, error) {
+	var meta struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	apiVersion := kind.GroupVersionKind().GroupVersion().String()
+	if meta.APIVersion != apiVersion {
+		return nil, fmt.Errorf("stored as %s, only %s %s objects are supported, take the backup with the EnableAPIGroupVersions feature to include all versions", meta.APIVersion, apiVersion, kind.Kind)
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	return obj, err
+}
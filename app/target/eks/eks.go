@@ -46,8 +46,16 @@ func (c EKS) DeployResources(sCluster *cluster.Cluster, srcResources *resource.R
 		target_impl.Deploy_resource_eks(sCluster, srcResources)
 	}
 
+	// Delete only what a migration run created
+	if action == "Rollback" {
+		target_impl.Rollback_run(sCluster)
+	}
+
 	// Functions to delete resource from Destination EKS cluster
 	if action == "Delete" {
+		if !target_impl.Confirm_delete(sCluster, srcResources) {
+			return
+		}
 		target_impl.Delete_helm_charts(sCluster, srcResources)
 		target_impl.Delete_resource_eks(sCluster, srcResources)
 	}
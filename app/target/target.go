@@ -20,6 +20,9 @@
 package source
 
 import (
+	"context"
+	"strings"
+
 	cluster "containers-migration-factory/app/cluster"
 	resource "containers-migration-factory/app/resource"
 )
@@ -38,12 +41,19 @@ func SetContext(target Target,dCluster *cluster.Cluster){
 }
 
 // Invoke specific source based on input provided
-func Invoke(target Target, sType string, sCluster *cluster.Cluster, dCluster *cluster.Cluster,srcResources *resource.Resources, action string) string {
+func Invoke(ctx context.Context, target Target, sType string, sCluster *cluster.Cluster, dCluster *cluster.Cluster,srcResources *resource.Resources, action string) string {
 	
 	/*Connect to target cluster*/
 	// target.Connect(dCluster)
 
+	/*Cancelling ctx stops the deploy and writes the checkpoint*/
+
+	dCluster.SetCtx(ctx)
+	dCluster.GetCheckpoint().Start(strings.ToLower(action))
+
 	target.DeployResources(dCluster,srcResources,action)
+	dCluster.Exit_if_interrupted()
+	dCluster.GetCheckpoint().Flush()
 
 	/*Get Source Details*/
 
@@ -0,0 +1,134 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"fmt"
+	"os"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	podsecuritypolicy "k8s.io/api/policy/v1beta1"
+	rbac "k8s.io/api/rbac/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+// Order in which the cluster scoped kinds are created, once and before the namespaces: what the workloads use first
+// and the ClusterRoles before their bindings
+var cluster_steps = []deploy_step{
+	{"StorageClass", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.StorageV1().StorageClasses().Create(dst.GetCtx(), obj.(*storage.StorageClass), metav1.CreateOptions{})
+		return err
+	}},
+	{"PodSecurityPolicy", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.PolicyV1beta1().PodSecurityPolicies().Create(dst.GetCtx(), obj.(*podsecuritypolicy.PodSecurityPolicy), metav1.CreateOptions{})
+		return err
+	}},
+	{"ClusterRole", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.RbacV1().ClusterRoles().Create(dst.GetCtx(), obj.(*rbac.ClusterRole), metav1.CreateOptions{})
+		return err
+	}},
+	{"ClusterRoleBinding", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.RbacV1().ClusterRoleBindings().Create(dst.GetCtx(), obj.(*rbac.ClusterRoleBinding), metav1.CreateOptions{})
+		return err
+	}},
+}
+
+// Webhook configurations are created once the namespaces are deployed: their services must be running, a webhook
+// failing closed would otherwise reject the creation of the objects it intercepts
+var webhook_steps = []deploy_step{
+	{"MutatingWebhookConfiguration", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Create(dst.GetCtx(), obj.(*admissionregistration.MutatingWebhookConfiguration), metav1.CreateOptions{})
+		return err
+	}},
+	{"ValidatingWebhookConfiguration", func(dst *cluster.Cluster, _ string, obj resource.Object) error {
+		_, err := dst.Clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(dst.GetCtx(), obj.(*admissionregistration.ValidatingWebhookConfiguration), metav1.CreateOptions{})
+		return err
+	}},
+}
+
+// Deploy_cluster_scoped creates the cluster scoped objects selected by RESOURCES once, before the namespaces. Objects
+// the destination platform brings itself, e.g. the eks:* ClusterRoles or the gp2 StorageClass, are not deployed.
+func Deploy_cluster_scoped(dst *cluster.Cluster, src_resources *resource.Resources) {
+	deploy_cluster_steps(dst, src_resources, cluster_steps)
+}
+
+// Deploy_webhooks creates the webhook configurations selected by RESOURCES once, after the namespaces
+func Deploy_webhooks(dst *cluster.Cluster, src_resources *resource.Resources) {
+	deploy_cluster_steps(dst, src_resources, webhook_steps)
+}
+
+func deploy_cluster_steps(dst *cluster.Cluster, src_resources *resource.Resources, steps []deploy_step) {
+	objects := make(map[string][]resource.Object)
+	src_resources.Each(func(kind string, obj resource.Object) {
+		if kind != "Namespace" && obj.GetNamespace() == "" {
+			objects[kind] = append(objects[kind], obj)
+		}
+	})
+
+	for _, step := range steps {
+		if !selected(step.kind, dst.Resources) || len(objects[step.kind]) == 0 {
+			continue
+		}
+		k, _ := resource.Find_kind(step.kind)
+		if !served(dst, k) {
+			fmt.Println(plural(step.kind), "are not served by the destination cluster, skipped")
+			continue
+		}
+		fmt.Println("===============")
+		fmt.Println("Creating", plural(step.kind))
+		for _, obj := range objects[step.kind] {
+			dst.Exit_if_interrupted()
+			if dst.GetIgnore().Matches(step.kind, obj) {
+				fmt.Printf("%s %s is owned by the destination platform, skipped\n", step.kind, obj.GetName())
+				if src_resources.Report != nil {
+					src_resources.Report.Add("deploy", step.kind, "", obj.GetName(), "owned by the destination platform, not deployed")
+				}
+				continue
+			}
+			obj, create := obj, step.create
+			create_once(dst, step.kind, "", obj.GetName(), func() error { return create(dst, "", obj) }, os.Stdout)
+			verify(dst, step.kind, "", obj.GetName(), os.Stdout)
+		}
+	}
+	dst.Exit_if_interrupted()
+	dst.GetCheckpoint().Save()
+}
+
+// served tells whether the destination cluster serves a kind, e.g. PodSecurityPolicy is gone since Kubernetes 1.25
+func served(dst *cluster.Cluster, k resource.Kind) bool {
+	resources, err := dst.Clientset.Discovery().ServerResourcesForGroupVersion(k.GroupVersionKind().GroupVersion().String())
+	if errors.IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		// the creation reports what is wrong with the cluster
+		return true
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == k.Resource {
+			return true
+		}
+	}
+	return false
+}
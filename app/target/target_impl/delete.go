@@ -0,0 +1,209 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	checkpoint "containers-migration-factory/app/checkpoint"
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+	transform "containers-migration-factory/app/transform"
+)
+
+// Time between two checks while waiting for the deleted objects to be gone
+const wait_interval = 2 * time.Second
+
+// Helm releases are not a kind of the registry, RESOURCES selects them with all or one of these names
+var helm_release = resource.Kind{Kind: "HelmRelease", Names: []string{"helmrelease", "helmreleases", "helm"}}
+
+// object deleted from the destination cluster, waited for when DELETE_WAIT is set
+type deleted_object struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// Delete_resource_eks deletes the scanned objects of every kind selected by RESOURCES from the destination cluster, in
+// the reverse order of the deploy: the namespaced objects namespace by namespace, then the cluster scoped objects and
+// the namespaces last when RESOURCES selects them. Cluster scoped objects are shared by the whole cluster, they are only
+// deleted when KMF created them.
+func Delete_resource_eks(dst *cluster.Cluster, src_resources *resource.Resources) {
+	buckets := bucket_by_namespace(src_resources)
+	order := rollback_order()
+
+	for _, element := range src_resources.Nsl.Items {
+		namespace := element.ObjectMeta.Name
+		dst.Exit_if_interrupted()
+		fmt.Println("=====================================================================")
+		fmt.Println("Operating on namespace: ", namespace)
+		fmt.Println("=====================================================================")
+		var deleted []deleted_object
+		for _, kind := range order {
+			k, ok := resource.Find_kind(kind)
+			if !ok || !k.Namespaced || !k.Selected(dst.Resources) || len(buckets[namespace][kind]) == 0 {
+				continue
+			}
+			fmt.Println("===============")
+			fmt.Println("Deleting", plural(kind))
+			for _, obj := range buckets[namespace][kind] {
+				dst.Exit_if_interrupted()
+				if delete_object(dst, k, namespace, obj.GetName(), false) {
+					deleted = append(deleted, deleted_object{kind, namespace, obj.GetName()})
+				}
+			}
+		}
+		wait_deleted(dst, deleted)
+		dst.GetCheckpoint().Namespace_done(namespace)
+		dst.GetCheckpoint().Save()
+	}
+
+	// Delete the cluster scoped objects once
+	cluster_scoped := make(map[string][]resource.Object)
+	src_resources.Each(func(kind string, obj resource.Object) {
+		if kind != "Namespace" && obj.GetNamespace() == "" {
+			cluster_scoped[kind] = append(cluster_scoped[kind], obj)
+		}
+	})
+	var deleted []deleted_object
+	for _, kind := range order {
+		k, ok := resource.Find_kind(kind)
+		if !ok || k.Namespaced || !k.Selected(dst.Resources) || len(cluster_scoped[kind]) == 0 {
+			continue
+		}
+		fmt.Println("===============")
+		fmt.Println("Deleting", plural(kind))
+		for _, obj := range cluster_scoped[kind] {
+			dst.Exit_if_interrupted()
+			if delete_object(dst, k, "", obj.GetName(), true) {
+				deleted = append(deleted, deleted_object{kind, "", obj.GetName()})
+			}
+		}
+	}
+	wait_deleted(dst, deleted)
+
+	// Delete list of namespaces in destination cluster
+	if k, _ := resource.Find_kind("Namespace"); !k.Selected(dst.Resources) {
+		return
+	}
+	deleted = nil
+	for _, element := range src_resources.Nsl.Items {
+		dst.Exit_if_interrupted()
+		fmt.Println("Deleting Namespace: ", element.ObjectMeta.Name)
+		err := delete_namespace(dst, element.ObjectMeta.Name, src_resources.HelmList[element.ObjectMeta.Name])
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		dst.GetCheckpoint().Set("Namespace", "", element.ObjectMeta.Name, checkpoint.Deleted)
+		deleted = append(deleted, deleted_object{"Namespace", "", element.ObjectMeta.Name})
+	}
+	wait_deleted(dst, deleted)
+}
+
+// Delete an object of any kind with the propagation policy of the destination cluster, only_kmf leaves the object
+// alone unless it carries the run ID label of a KMF run. Returns true once the deletion was accepted.
+func delete_object(dst *cluster.Cluster, k resource.Kind, namespace string, name string, only_kmf bool) bool {
+	state := dst.GetCheckpoint()
+	if only_kmf {
+		current, err := get(dst, k.Kind, namespace, name)
+		if err != nil {
+			dst.Exit_if_interrupted()
+			fmt.Println(err)
+			return false
+		}
+		if !created_by_kmf(current) {
+			fmt.Printf("%s %s was not created by KMF, left untouched\n", k.Kind, name)
+			return false
+		}
+	}
+
+	fmt.Printf("Deleting %s: %s\n", k.Kind, qualified(created_object{namespace, name}))
+	client := dst.GetDynamic().Resource(k.GroupVersionResource())
+	var err error
+	if k.Namespaced {
+		err = client.Namespace(namespace).Delete(dst.GetCtx(), name, delete_options(dst))
+	} else {
+		err = client.Delete(dst.GetCtx(), name, delete_options(dst))
+	}
+	if errors.IsNotFound(err) {
+		fmt.Println(err)
+		return false
+	}
+	if err != nil {
+		dst.Exit_if_interrupted()
+		fmt.Println(err)
+		state.Fail(k.Kind, namespace, name, err)
+		return false
+	}
+	state.Set(k.Kind, namespace, name, checkpoint.Deleted)
+	return true
+}
+
+// Propagation policy of DELETE_PROPAGATION, the dependents are deleted in the background by default
+func delete_options(dst *cluster.Cluster) metav1.DeleteOptions {
+	policy := metav1.DeletePropagationBackground
+	if dst.GetDelete_propagation() != "" {
+		policy = metav1.DeletionPropagation(dst.GetDelete_propagation())
+	}
+	return metav1.DeleteOptions{PropagationPolicy: &policy}
+}
+
+// Wait up to DELETE_WAIT for the deleted objects to be gone, e.g. until the finalizers ran or, with Foreground
+// propagation, the dependents were deleted. Nothing is waited for when DELETE_WAIT is not set.
+func wait_deleted(dst *cluster.Cluster, objects []deleted_object) {
+	timeout := dst.GetDelete_wait()
+	if timeout <= 0 || len(objects) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(dst.GetCtx(), timeout)
+	defer cancel()
+
+	fmt.Printf("Waiting up to %v for %d deleted objects to be gone\n", timeout, len(objects))
+	for {
+		remaining := objects[:0]
+		for _, obj := range objects {
+			if _, err := get(dst, obj.kind, obj.namespace, obj.name); !errors.IsNotFound(err) {
+				remaining = append(remaining, obj)
+			}
+		}
+		if objects = remaining; len(objects) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			dst.Exit_if_interrupted()
+			for _, obj := range objects {
+				fmt.Printf("%s %s is still being deleted after %v\n", obj.kind, qualified(created_object{obj.namespace, obj.name}), timeout)
+			}
+			return
+		case <-time.After(wait_interval):
+		}
+	}
+}
+
+func created_by_kmf(obj metav1.Object) bool {
+	_, ok := obj.GetLabels()[transform.Run_id_label]
+	return ok
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+// Namespaces the Delete action never touches, PROTECTED_NAMESPACES adds to them
+var protected_namespaces = []string{"default", "kube-system", "kube-public", "kube-node-lease"}
+
+// Objects not created by KMF printed per namespace in the preview
+const foreign_preview = 5
+
+// Confirm_delete leaves the protected namespaces out of the resources, prints what the Delete action is about to
+// delete and asks for confirmation unless it was given up front. It returns false when nothing is to be deleted.
+func Confirm_delete(dst *cluster.Cluster, src_resources *resource.Resources) bool {
+	for _, namespace := range protected(dst, src_resources) {
+		fmt.Println("Namespace", namespace, "is protected, nothing in it is deleted")
+	}
+
+	fmt.Println("=====================================================================")
+	fmt.Println("Delete preview on context:", dst.GetContext())
+	fmt.Println("=====================================================================")
+	buckets := bucket_by_namespace(src_resources)
+	namespaces, _ := resource.Find_kind("Namespace")
+	total := 0
+	for _, element := range src_resources.Nsl.Items {
+		namespace := element.ObjectMeta.Name
+		fmt.Println("Namespace:", namespace)
+		if helm_release.Selected(dst.Resources) {
+			for release := range src_resources.HelmList[namespace] {
+				fmt.Println("  HelmRelease", release)
+				total++
+			}
+		}
+		for _, kind := range sorted_kinds(buckets[namespace]) {
+			for _, obj := range buckets[namespace][kind] {
+				fmt.Println(" ", kind, obj.GetName())
+				total++
+			}
+		}
+
+		if !namespaces.Selected(dst.Resources) {
+			fmt.Println("  the namespace is kept, RESOURCES does not select namespaces")
+			continue
+		}
+		foreign, err := foreign_objects(dst, namespace, src_resources.HelmList[namespace])
+		switch {
+		case errors.IsNotFound(err):
+			fmt.Println("  the namespace does not exist in the destination cluster")
+		case err != nil:
+			dst.Exit_if_interrupted()
+			fmt.Println("  the namespace is kept, its content could not be read:", err)
+		case len(foreign) > 0:
+			fmt.Printf("  the namespace is kept, it holds %d objects not created by KMF: %s\n", len(foreign), preview(foreign))
+		default:
+			fmt.Println("  the namespace is deleted")
+			total++
+		}
+	}
+	fmt.Println("=====================================================================")
+
+	if total == 0 {
+		fmt.Println("Nothing to delete")
+		return false
+	}
+	if dst.GetAssume_yes() {
+		fmt.Println(total, "objects are deleted, confirmed up front")
+		return true
+	}
+	fmt.Printf("Delete the %d objects listed above from context %s? Type yes to continue: ", total, dst.GetContext())
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		fmt.Println("Delete cancelled, nothing was deleted")
+		return false
+	}
+	return true
+}
+
+// Delete a namespace of the destination cluster only when KMF created it and everything left in it
+func delete_namespace(dst *cluster.Cluster, namespace string, releases map[string]string) error {
+	foreign, err := foreign_objects(dst, namespace, releases)
+	if err != nil {
+		return err
+	}
+	if len(foreign) > 0 {
+		return fmt.Errorf("namespace %s is kept, it holds %d objects not created by KMF: %s", namespace, len(foreign), preview(foreign))
+	}
+	return dst.Clientset.CoreV1().Namespaces().Delete(dst.GetCtx(), namespace, delete_options(dst))
+}
+
+// Remove the protected namespaces and everything in them from the resources, returns the namespaces removed
+func protected(dst *cluster.Cluster, src_resources *resource.Resources) []string {
+	names := append(append([]string{}, protected_namespaces...), dst.GetProtected_namespaces()...)
+	var removed []string
+	src_resources.Filter(func(kind string, obj resource.Object) bool {
+		namespace := obj.GetNamespace()
+		if kind == "Namespace" {
+			namespace = obj.GetName()
+		}
+		if !contains(names, namespace) {
+			return true
+		}
+		if !contains(removed, namespace) {
+			removed = append(removed, namespace)
+		}
+		return false
+	})
+	for namespace := range src_resources.HelmList {
+		if contains(names, namespace) {
+			delete(src_resources.HelmList, namespace)
+			if !contains(removed, namespace) {
+				removed = append(removed, namespace)
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// Object of a namespace read back from the destination cluster
+type namespaced_object struct {
+	kind string
+	obj  *unstructured.Unstructured
+}
+
+// Objects of a namespace of the destination cluster that do not carry the run ID label of a KMF run, the namespace
+// itself included. Every namespaced resource the cluster serves is read, not only the kinds KMF migrates. The objects
+// Kubernetes adds to every namespace, the Events, the objects of the Helm releases deleted with it and the objects
+// owned by the ones KMF created, e.g. the ReplicaSets and Pods of a Deployment or the Endpoints of a Service, do not count.
+func foreign_objects(dst *cluster.Cluster, namespace string, releases map[string]string) ([]string, error) {
+	ns, err := get(dst, "Namespace", "", namespace)
+	if err != nil {
+		return nil, err
+	}
+	var foreign []string
+	if !created_by_kmf(ns) {
+		foreign = append(foreign, "Namespace/"+namespace)
+	}
+
+	// a group that cannot be discovered may hold anything, the namespace is kept
+	lists, err := dst.Clientset.Discovery().ServerPreferredNamespacedResources()
+	if err != nil {
+		return nil, err
+	}
+	var objects []namespaced_object
+	namespaced := make(map[schema.GroupKind]bool)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			if r.Name == "events" || strings.Contains(r.Name, "/") || !contains(r.Verbs, "list") || !contains(r.Verbs, "delete") {
+				continue
+			}
+			namespaced[gv.WithKind(r.Kind).GroupKind()] = true
+			items, err := list_namespace(dst, gv.WithResource(r.Name), namespace)
+			if err != nil {
+				return nil, err
+			}
+			for i := range items {
+				objects = append(objects, namespaced_object{r.Kind, &items[i]})
+			}
+		}
+	}
+
+	present := make(map[types.UID]bool)
+	services := make(map[string]types.UID)
+	for _, o := range objects {
+		present[o.obj.GetUID()] = true
+		if o.kind == "Service" {
+			services[o.obj.GetName()] = o.obj.GetUID()
+		}
+	}
+	// what KMF created and what it owns, directly or through other owned objects
+	kept := make(map[types.UID]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, o := range objects {
+			if kept[o.obj.GetUID()] {
+				continue
+			}
+			if created_by_kmf(o.obj) || added_by_kubernetes(o.kind, o.obj) || in_release(o.obj, releases) || owned(o.obj, kept, present, namespaced) ||
+				o.kind == "Endpoints" && kept[services[o.obj.GetName()]] {
+				kept[o.obj.GetUID()] = true
+				changed = true
+			}
+		}
+	}
+	for _, o := range objects {
+		if !kept[o.obj.GetUID()] {
+			foreign = append(foreign, o.kind+"/"+o.obj.GetName())
+		}
+	}
+	return foreign, nil
+}
+
+// Every object of a resource in a namespace, a resource the cluster stopped serving holds nothing
+func list_namespace(dst *cluster.Cluster, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	client := dst.GetDynamic().Resource(gvr).Namespace(namespace)
+	options := metav1.ListOptions{Limit: 500}
+	var items []unstructured.Unstructured
+	for {
+		list, err := client.List(dst.GetCtx(), options)
+		if errors.IsNotFound(err) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+		if options.Continue = list.GetContinue(); options.Continue == "" {
+			return items, nil
+		}
+	}
+}
+
+// An object is owned by KMF when one of its owners is kept or, for a namespaced owner already deleted, when the
+// garbage collector is about to delete it anyway
+func owned(obj metav1.Object, kept map[types.UID]bool, present map[types.UID]bool, namespaced map[schema.GroupKind]bool) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if kept[ref.UID] {
+			return true
+		}
+		if !present[ref.UID] && namespaced[schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind()] {
+			return true
+		}
+	}
+	return false
+}
+
+// The default service account, its token and the root CA are created in every namespace
+func added_by_kubernetes(kind string, obj metav1.Object) bool {
+	switch kind {
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "ConfigMap":
+		return obj.GetName() == "kube-root-ca.crt"
+	case "Secret":
+		return obj.GetAnnotations()["kubernetes.io/service-account.name"] != ""
+	}
+	return false
+}
+
+// Objects rendered by a Helm release and the secrets Helm stores the release in
+func in_release(obj metav1.Object, releases map[string]string) bool {
+	if _, ok := releases[obj.GetAnnotations()["meta.helm.sh/release-name"]]; ok {
+		return true
+	}
+	_, ok := releases[obj.GetLabels()["name"]]
+	return ok && obj.GetLabels()["owner"] == "helm"
+}
+
+func sorted_kinds(objects map[string][]resource.Object) []string {
+	var kinds []string
+	for kind := range objects {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func preview(objects []string) string {
+	if len(objects) > foreign_preview {
+		return strings.Join(objects[:foreign_preview], ", ") + fmt.Sprintf(" and %d more", len(objects)-foreign_preview)
+	}
+	return strings.Join(objects, ", ")
+}
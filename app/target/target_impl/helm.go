@@ -0,0 +1,57 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	cluster "containers-migration-factory/app/cluster"
+)
+
+// Helm command run against the destination cluster, with the kubeconfig and context chosen for it rather than the
+// current context of the default kubeconfig
+func helm_command(dst *cluster.Cluster, args ...string) *exec.Cmd {
+	if dst.GetKubeconfig_path() != "" {
+		args = append(args, "--kubeconfig", dst.GetKubeconfig_path())
+	}
+	if dst.GetContext() != "" {
+		args = append(args, "--kube-context", dst.GetContext())
+	}
+	return exec.CommandContext(dst.GetCtx(), "helm", args...)
+}
+
+// Current revision of a Helm release of the destination cluster, 0 when the release does not exist
+func helm_revision(dst *cluster.Cluster, namespace string, name string) (int, error) {
+	out, err := helm_command(dst, "status", name, "-n", namespace, "-o", "json").Output()
+	if exit, ok := err.(*exec.ExitError); ok && strings.Contains(string(exit.Stderr), "release: not found") {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var status struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		return 0, err
+	}
+	return status.Version, nil
+}
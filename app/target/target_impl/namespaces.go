@@ -0,0 +1,222 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	app "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+)
+
+// Namespaces deployed at the same time when the destination cluster does not set its own number of workers
+const default_deploy_workers = 4
+
+// deploy_step creates the objects of one kind in a namespace
+type deploy_step struct {
+	kind   string
+	create func(dst *cluster.Cluster, namespace string, obj resource.Object) error
+}
+
+// Order in which the kinds are created inside a namespace, the objects referenced by the workloads come first: the
+// configuration, the claims and the ServiceAccounts the pods run as with their Roles and RoleBindings
+var deploy_steps = []deploy_step{
+	{"Secret", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.CoreV1().Secrets(namespace).Create(dst.GetCtx(), obj.(*v1.Secret), metav1.CreateOptions{})
+		return err
+	}},
+	{"ConfigMap", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.CoreV1().ConfigMaps(namespace).Create(dst.GetCtx(), obj.(*v1.ConfigMap), metav1.CreateOptions{})
+		return err
+	}},
+	{"PersistentVolumeClaim", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.CoreV1().PersistentVolumeClaims(namespace).Create(dst.GetCtx(), obj.(*v1.PersistentVolumeClaim), metav1.CreateOptions{})
+		return err
+	}},
+	{"ServiceAccount", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.CoreV1().ServiceAccounts(namespace).Create(dst.GetCtx(), obj.(*v1.ServiceAccount), metav1.CreateOptions{})
+		return err
+	}},
+	{"Role", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.RbacV1().Roles(namespace).Create(dst.GetCtx(), obj.(*rbac.Role), metav1.CreateOptions{})
+		return err
+	}},
+	{"RoleBinding", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.RbacV1().RoleBindings(namespace).Create(dst.GetCtx(), obj.(*rbac.RoleBinding), metav1.CreateOptions{})
+		return err
+	}},
+	{"Deployment", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.AppsV1().Deployments(namespace).Create(dst.GetCtx(), obj.(*app.Deployment), metav1.CreateOptions{})
+		return err
+	}},
+	{"StatefulSet", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.AppsV1().StatefulSets(namespace).Create(dst.GetCtx(), obj.(*app.StatefulSet), metav1.CreateOptions{})
+		return err
+	}},
+	{"Service", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		// the destination cluster allocates its own cluster IPs and node ports
+		svc := obj.(*v1.Service).DeepCopy()
+		svc.Spec.ClusterIP = ""
+		for port := range svc.Spec.Ports {
+			svc.Spec.Ports[port].NodePort = 0
+		}
+		_, err := dst.Clientset.CoreV1().Services(namespace).Create(dst.GetCtx(), svc, metav1.CreateOptions{})
+		return err
+	}},
+	{"DaemonSet", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.AppsV1().DaemonSets(namespace).Create(dst.GetCtx(), obj.(*app.DaemonSet), metav1.CreateOptions{})
+		return err
+	}},
+	{"Ingress", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.NetworkingV1().Ingresses(namespace).Create(dst.GetCtx(), obj.(*networking.Ingress), metav1.CreateOptions{})
+		return err
+	}},
+	{"CronJob", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.BatchV1beta1().CronJobs(namespace).Create(dst.GetCtx(), obj.(*batchv1beta1.CronJob), metav1.CreateOptions{})
+		return err
+	}},
+	{"Job", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.BatchV1().Jobs(namespace).Create(dst.GetCtx(), obj.(*batchv1.Job), metav1.CreateOptions{})
+		return err
+	}},
+	{"HorizontalPodAutoscaler", func(dst *cluster.Cluster, namespace string, obj resource.Object) error {
+		_, err := dst.Clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Create(dst.GetCtx(), obj.(*autoscaling.HorizontalPodAutoscaler), metav1.CreateOptions{})
+		return err
+	}},
+}
+
+// Group the namespaced objects by namespace and kind in one pass
+func bucket_by_namespace(src_resources *resource.Resources) map[string]map[string][]resource.Object {
+	buckets := make(map[string]map[string][]resource.Object)
+	src_resources.Each(func(kind string, obj resource.Object) {
+		namespace := obj.GetNamespace()
+		if namespace == "" {
+			return
+		}
+		if buckets[namespace] == nil {
+			buckets[namespace] = make(map[string][]resource.Object)
+		}
+		buckets[namespace][kind] = append(buckets[namespace][kind], obj)
+	})
+	return buckets
+}
+
+// Deploy the namespaces in parallel, up to the number of workers of the destination cluster. Inside a namespace the
+// kinds are created in the order of deploy_steps, the output of a namespace is printed in one piece once it is done.
+func Deploy_namespaces(dst *cluster.Cluster, src_resources *resource.Resources) {
+	buckets := bucket_by_namespace(src_resources)
+	workers := dst.GetWorkers()
+	if workers <= 0 {
+		workers = default_deploy_workers
+	}
+
+	var (
+		print_lock sync.Mutex
+		wg         sync.WaitGroup
+		namespaces = make(chan string)
+	)
+	for w := 0; w < workers && w < len(src_resources.Nsl.Items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for namespace := range namespaces {
+				// once interrupted the namespaces not started yet are left for the checkpoint
+				if dst.GetCtx().Err() != nil {
+					continue
+				}
+				var log bytes.Buffer
+				if deploy_namespace(dst, namespace, buckets[namespace], &log) {
+					dst.GetCheckpoint().Namespace_done(namespace)
+				}
+				dst.GetCheckpoint().Save()
+
+				print_lock.Lock()
+				fmt.Print(log.String())
+				print_lock.Unlock()
+			}
+		}()
+	}
+	for _, element := range src_resources.Nsl.Items {
+		namespaces <- element.ObjectMeta.Name
+	}
+	close(namespaces)
+	wg.Wait()
+	dst.Exit_if_interrupted()
+}
+
+// deploy_namespace returns false when the migration was interrupted before every object of the namespace was handled
+func deploy_namespace(dst *cluster.Cluster, namespace string, objects map[string][]resource.Object, log *bytes.Buffer) bool {
+	fmt.Fprintln(log, "=====================================================================")
+	fmt.Fprintln(log, "Operating on namespace: ", namespace)
+	fmt.Fprintln(log, "=====================================================================")
+
+	for _, step := range deploy_steps {
+		if !selected(step.kind, dst.Resources) || len(objects[step.kind]) == 0 {
+			continue
+		}
+		fmt.Fprintln(log, "===============")
+		fmt.Fprintln(log, "Creating", plural(step.kind))
+		for _, obj := range objects[step.kind] {
+			if dst.GetCtx().Err() != nil {
+				return false
+			}
+			obj, create := obj, step.create
+			create_once(dst, step.kind, namespace, obj.GetName(), func() error { return create(dst, namespace, obj) }, log)
+		}
+	}
+	if dst.GetCtx().Err() != nil {
+		return false
+	}
+
+	// read back what was created once the whole namespace is deployed
+	for _, step := range deploy_steps {
+		for _, obj := range objects[step.kind] {
+			verify(dst, step.kind, namespace, obj.GetName(), log)
+		}
+	}
+	return dst.GetCtx().Err() == nil
+}
+
+// selected tells whether RESOURCES selects a kind
+func selected(kind string, resources []string) bool {
+	k, ok := resource.Find_kind(kind)
+	return ok && k.Selected(resources)
+}
+
+func plural(kind string) string {
+	if strings.HasSuffix(kind, "s") {
+		return kind + "es"
+	}
+	if strings.HasSuffix(kind, "cy") {
+		return strings.TrimSuffix(kind, "y") + "ies"
+	}
+	return kind + "s"
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	checkpoint "containers-migration-factory/app/checkpoint"
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+	transform "containers-migration-factory/app/transform"
+)
+
+// Create an object unless the run, or the run it resumes, already created it. An object that already exists counts
+// as created when it carries the run ID, it was created by the run being resumed before its checkpoint was saved.
+func create_once(dst *cluster.Cluster, kind string, namespace string, name string, create func() error, log io.Writer) {
+	state := dst.GetCheckpoint()
+	if state.Reached(kind, namespace, name, checkpoint.Created) {
+		fmt.Fprintf(log, "%s %s already created by run %s, skipped\n", kind, name, dst.GetRun_id())
+		return
+	}
+	fmt.Fprintf(log, "Creating %s: %s\n", kind, name)
+	err := create()
+	if errors.IsAlreadyExists(err) && created_by_run(dst, kind, namespace, name) {
+		err = nil
+	}
+	if err != nil {
+		fmt.Fprintln(log, err)
+		state.Fail(kind, namespace, name, err)
+		return
+	}
+	state.Set(kind, namespace, name, checkpoint.Created)
+}
+
+// Read back the objects created by the run, an object that cannot be read is failed and created again on resume
+func verify(dst *cluster.Cluster, kind string, namespace string, name string, log io.Writer) {
+	state := dst.GetCheckpoint()
+	if !state.Reached(kind, namespace, name, checkpoint.Created) || state.Reached(kind, namespace, name, checkpoint.Verified) {
+		return
+	}
+	if _, err := get(dst, kind, namespace, name); err != nil {
+		fmt.Fprintf(log, "Could not verify %s %s: %v\n", kind, name, err)
+		state.Fail(kind, namespace, name, err)
+		return
+	}
+	state.Set(kind, namespace, name, checkpoint.Verified)
+}
+
+func created_by_run(dst *cluster.Cluster, kind string, namespace string, name string) bool {
+	obj, err := get(dst, kind, namespace, name)
+	return err == nil && dst.GetRun_id() != "" && obj.GetLabels()[transform.Run_id_label] == dst.GetRun_id()
+}
+
+// Read an object of the destination cluster whatever its kind
+func get(dst *cluster.Cluster, kind string, namespace string, name string) (metav1.Object, error) {
+	k, ok := resource.Find_kind(kind)
+	if !ok || dst.GetDynamic() == nil {
+		return nil, fmt.Errorf("kind %s cannot be read back", kind)
+	}
+	if k.Namespaced {
+		return dst.GetDynamic().Resource(k.GroupVersionResource()).Namespace(namespace).Get(dst.GetCtx(), name, metav1.GetOptions{})
+	}
+	return dst.GetDynamic().Resource(k.GroupVersionResource()).Get(dst.GetCtx(), name, metav1.GetOptions{})
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package AWS
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	checkpoint "containers-migration-factory/app/checkpoint"
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+	transform "containers-migration-factory/app/transform"
+)
+
+// object of the destination cluster created by the run rolled back
+type created_object struct {
+	namespace string
+	name      string
+}
+
+// Rollback_run deletes what the run of dst.GetRun_id() created, and only that: the objects recorded in its checkpoint
+// and the objects carrying its run ID label. An object is deleted only while it still carries the label, so objects
+// that existed before the run are left untouched. The kinds are deleted in the reverse order of the deploy.
+func Rollback_run(dst *cluster.Cluster) {
+	run_id := dst.GetRun_id()
+	fmt.Println("Rolling back run", run_id, "....start")
+	inventory := created_by(dst, run_id)
+
+	for _, kind := range rollback_order() {
+		if kind == "HelmRelease" {
+			rollback_releases(dst, inventory[kind])
+			continue
+		}
+		for _, obj := range inventory[kind] {
+			dst.Exit_if_interrupted()
+			delete_created(dst, kind, obj, run_id)
+		}
+		dst.GetCheckpoint().Save()
+	}
+	fmt.Println("Rolling back run", run_id, "....End")
+}
+
+// Kinds in the order they are deleted: the webhooks first so they do not call deleted services, then the namespaced
+// kinds in the reverse order of deploy_steps, the Helm releases, the cluster scoped kinds in the reverse order of
+// cluster_steps and the namespaces last
+func rollback_order() []string {
+	var order []string
+	for i := len(webhook_steps) - 1; i >= 0; i-- {
+		order = append(order, webhook_steps[i].kind)
+	}
+	for i := len(deploy_steps) - 1; i >= 0; i-- {
+		order = append(order, deploy_steps[i].kind)
+	}
+	order = append(order, "HelmRelease")
+	for i := len(cluster_steps) - 1; i >= 0; i-- {
+		if !contains(order, cluster_steps[i].kind) {
+			order = append(order, cluster_steps[i].kind)
+		}
+	}
+	for i := len(resource.Kinds) - 1; i >= 0; i-- {
+		if kind := resource.Kinds[i].Kind; kind != "Namespace" && !contains(order, kind) {
+			order = append(order, kind)
+		}
+	}
+	return append(order, "Namespace")
+}
+
+// Inventory of the run by kind: what its checkpoint recorded as created and what carries its label
+func created_by(dst *cluster.Cluster, run_id string) map[string][]created_object {
+	seen := make(map[string]bool)
+	inventory := make(map[string][]created_object)
+	add := func(kind string, namespace string, name string) {
+		key := kind + "/" + namespace + "/" + name
+		if !seen[key] {
+			seen[key] = true
+			inventory[kind] = append(inventory[kind], created_object{namespace, name})
+		}
+	}
+
+	state := dst.GetCheckpoint()
+	if state != nil {
+		for _, obj := range state.Objects {
+			if obj.Status != checkpoint.Deleted && state.Reached(obj.Kind, obj.Namespace, obj.Name, checkpoint.Created) {
+				add(obj.Kind, obj.Namespace, obj.Name)
+			}
+		}
+	}
+
+	options := metav1.ListOptions{LabelSelector: transform.Run_id_label + "=" + run_id, Limit: 500}
+	for _, k := range resource.Kinds {
+		client := dst.GetDynamic().Resource(k.GroupVersionResource())
+		for options.Continue = ""; ; {
+			list, err := client.List(dst.GetCtx(), options)
+			if err != nil {
+				dst.Exit_if_interrupted()
+				// kinds the destination cluster no longer serves, e.g. PodSecurityPolicy, hold nothing to delete
+				if !errors.IsNotFound(err) {
+					fmt.Printf("Could not list the %s of run %s: %v\n", plural(k.Kind), run_id, err)
+				}
+				break
+			}
+			for _, item := range list.Items {
+				add(k.Kind, item.GetNamespace(), item.GetName())
+			}
+			if options.Continue = list.GetContinue(); options.Continue == "" {
+				break
+			}
+		}
+	}
+
+	for kind := range inventory {
+		sort.Slice(inventory[kind], func(i, j int) bool {
+			a, b := inventory[kind][i], inventory[kind][j]
+			return a.namespace < b.namespace || a.namespace == b.namespace && a.name < b.name
+		})
+	}
+	return inventory
+}
+
+func delete_created(dst *cluster.Cluster, kind string, obj created_object, run_id string) {
+	state := dst.GetCheckpoint()
+	current, err := get(dst, kind, obj.namespace, obj.name)
+	if errors.IsNotFound(err) {
+		state.Set(kind, obj.namespace, obj.name, checkpoint.Deleted)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Could not read %s %s: %v\n", kind, qualified(obj), err)
+		state.Fail(kind, obj.namespace, obj.name, err)
+		return
+	}
+	if current.GetLabels()[transform.Run_id_label] != run_id {
+		fmt.Printf("%s %s was not created by run %s, left untouched\n", kind, qualified(obj), run_id)
+		return
+	}
+
+	k, _ := resource.Find_kind(kind)
+	delete_object(dst, k, obj.namespace, obj.name, false)
+}
+
+// Releases are only known from the checkpoint. A release the run installed is uninstalled, a release it upgraded is
+// rolled back to the revision it had before the run and a release without a recorded revision is left untouched.
+func rollback_releases(dst *cluster.Cluster, releases []created_object) {
+	for _, release := range releases {
+		dst.Exit_if_interrupted()
+		revision, ok := dst.GetCheckpoint().Release(release.namespace, release.name)
+		var cmd *exec.Cmd
+		switch {
+		case !ok:
+			fmt.Printf("Helm release %s has no revision recorded before the run, left untouched\n", qualified(release))
+			continue
+		case revision > 0:
+			fmt.Println("Rolling back Chart ", release.name, " in namespace ", release.namespace, " to revision ", revision)
+			cmd = helm_command(dst, "rollback", release.name, strconv.Itoa(revision), "-n", release.namespace)
+		default:
+			fmt.Println("Uninstalling Chart ", release.name, " in namespace ", release.namespace)
+			cmd = helm_command(dst, "uninstall", release.name, "-n", release.namespace)
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			dst.Exit_if_interrupted()
+			fmt.Printf("Failed rolling back chart %s: %v %s\n", release.name, err, out)
+			dst.GetCheckpoint().Fail("HelmRelease", release.namespace, release.name, err)
+			continue
+		}
+		fmt.Printf(" %s\n", out)
+		dst.GetCheckpoint().Set("HelmRelease", release.namespace, release.name, checkpoint.Deleted)
+	}
+}
+
+func qualified(obj created_object) string {
+	if obj.namespace == "" {
+		return obj.name
+	}
+	return obj.namespace + "/" + obj.name
+}
+
+func contains(list []string, item string) bool {
+	for _, element := range list {
+		if element == item {
+			return true
+		}
+	}
+	return false
+}
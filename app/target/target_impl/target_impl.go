@@ -43,6 +43,7 @@ import (
 	"time"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/strvals"
+	checkpoint "containers-migration-factory/app/checkpoint"
 	cluster "containers-migration-factory/app/cluster"
 	resource "containers-migration-factory/app/resource"
 )
@@ -66,588 +67,33 @@ func stringInSlice(a string, list []string) bool {
 
 func Deploy_resource_eks(dst *cluster.Cluster, src_resources *resource.Resources) {
 
-	// Create non-namespaces resources
-	if stringInSlice("mutatingWebhookconfigurations", dst.Resources) || stringInSlice("mutatingwebhookconfiguration", dst.Resources) || stringInSlice("all", dst.Resources) {
-		// Create list of MutatingWebhookCOnfiguration in destination cluster
-		for _, element := range src_resources.MutatingWebhookConfigurationList {
-			element := element
-			fmt.Println("Creating MutatingWebhook: ", element.ObjectMeta.Name)
-			_, err := dst.Clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Create(context.TODO(), &element, metav1.CreateOptions{})
-			if err != nil {
-				fmt.Println(err)
-			}
-
-		}
-	}
-
-	// Create list of ValidatingWebhookCOnfiguration in destination cluster
-	if stringInSlice("validatingwebhookconfiguration", dst.Resources) || stringInSlice("validatingwebhookconfigurations", dst.Resources) || stringInSlice("all", dst.Resources) {
-		for _, element := range src_resources.ValidatingWebhookConfigurationList {
-			element := element
-			fmt.Println("Creating MutatingWebhook: ", element.ObjectMeta.Name)
-			_, err := dst.Clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(context.TODO(), &element, metav1.CreateOptions{})
-			if err != nil {
-				fmt.Println(err)
-			}
-		}
-	}
+	// Create the cluster scoped resources once, before the namespaces
+	Deploy_cluster_scoped(dst, src_resources)
 
 	// Create list of namespaces in destination cluster
 	for _, element := range src_resources.Nsl.Items {
 		element := element
-		fmt.Println("Creating the namespace: ", element.ObjectMeta.Name)
-		_, err := dst.Clientset.CoreV1().Namespaces().Create(context.TODO(), &element, metav1.CreateOptions{})
-		if err != nil {
-			fmt.Println(err)
-		}
+		create_once(dst, "Namespace", "", element.ObjectMeta.Name, func() error {
+			_, err := dst.Clientset.CoreV1().Namespaces().Create(dst.GetCtx(), &element, metav1.CreateOptions{})
+			return err
+		}, os.Stdout)
+		verify(dst, "Namespace", "", element.ObjectMeta.Name, os.Stdout)
 	}
+	dst.Exit_if_interrupted()
+	dst.GetCheckpoint().Save()
 
 	// Install/Upgrade helm charts 
 	Deploy_helm_charts(dst, src_resources)
+	dst.Exit_if_interrupted()
 
-	// Loop through each namespace and create resources inside each namespace
-	for _, element := range src_resources.Nsl.Items {
-		element := element
-
-		fmt.Println("=====================================================================")
-		fmt.Println("Operating on namespace: ", element.ObjectMeta.Name)
-		fmt.Println("=====================================================================")
-
-		// Create secrets resource
-		if stringInSlice("secrets", dst.Resources) || stringInSlice("secret", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Secrets")
-			for _, secret := range src_resources.SecretList {
-				secret := secret
-				if secret.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Secret: ", secret.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().Secrets(element.ObjectMeta.Name).Create(context.TODO(), &secret, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create ConfigMap resource
-		if stringInSlice("configmaps", dst.Resources) || stringInSlice("configmap", dst.Resources) || stringInSlice("cm", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating ConfigMap's")
-			for _, cm := range src_resources.ConfigMapsList {
-				cm := cm
-				if cm.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating ConfigMap: ", cm.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().ConfigMaps(element.ObjectMeta.Name).Create(context.TODO(), &cm, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create StorageClass resource
-		if stringInSlice("storageclasses", dst.Resources) || stringInSlice("storageclass", dst.Resources) || stringInSlice("sc", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating StorageClasses")
-			for _, sc := range src_resources.StorageClassList {
-				sc := sc
-				if sc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating StorageClass: ", sc.ObjectMeta.Name)
-					_, err := dst.Clientset.StorageV1().StorageClasses().Create(context.TODO(), &sc, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create PVC resource
-		if stringInSlice("persistentvolumeclaims", dst.Resources) || stringInSlice("persistentvolumeclaim", dst.Resources) || stringInSlice("pvc", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating PersistentVolumeClaims")
-			for _, pvc := range src_resources.PersistentVolumeClaimsList {
-				pvc := pvc
-				if pvc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating PVC: ", pvc.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().PersistentVolumeClaims(element.ObjectMeta.Name).Create(context.TODO(), &pvc, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create deployment resource
-		if stringInSlice("deployment", dst.Resources) || stringInSlice("deployments", dst.Resources) || stringInSlice("deploy", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Deployment")
-			for _, dep := range src_resources.Depl {
-				dep := dep
-				if dep.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Deployment: ", dep.ObjectMeta.Name)
-					_, err := dst.Clientset.AppsV1().Deployments(element.ObjectMeta.Name).Create(context.TODO(), &dep, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Service resource
-		if stringInSlice("service", dst.Resources) || stringInSlice("svc", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Service")
-			for _, svc := range src_resources.Svcl {
-				svc := svc
-				if svc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					svc.Spec.ClusterIP = ""
-					for port, _ := range svc.Spec.Ports {
-						svc.Spec.Ports[port].NodePort = 0
-					}
-					fmt.Println("Creating Service: ", svc.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().Services(element.ObjectMeta.Name).Create(context.TODO(), &svc, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Daemonset resource
-		if stringInSlice("daemonset", dst.Resources) || stringInSlice("daemonsets", dst.Resources) || stringInSlice("ds", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Daemonset")
-			for _, ds := range src_resources.Dsl {
-				ds := ds
-				if ds.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Daemonset: ", ds.ObjectMeta.Name)
-					_, err := dst.Clientset.AppsV1().DaemonSets(element.ObjectMeta.Name).Create(context.TODO(), &ds, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Ingress resource
-		if stringInSlice("ingresses", dst.Resources) || stringInSlice("ingress", dst.Resources) || stringInSlice("ing", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Ingresses")
-			for _, ingress := range src_resources.IngressList {
-				ingress := ingress
-				if ingress.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Ingress: ", ingress.ObjectMeta.Name)
-					_, err := dst.Clientset.NetworkingV1().Ingresses(element.ObjectMeta.Name).Create(context.TODO(), &ingress, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Roles resource
-		if stringInSlice("role", dst.Resources) || stringInSlice("roles", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Roles")
-			for _, rl := range src_resources.RoleList {
-				rl := rl
-				if rl.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Roles: ", rl.ObjectMeta.Name)
-					_, err := dst.Clientset.RbacV1().Roles(element.ObjectMeta.Name).Create(context.TODO(), &rl, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Role Bindings resource
-		if stringInSlice("rolebinding", dst.Resources) || stringInSlice("rolebindings", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Role Bindings")
-			for _, rbl := range src_resources.RoleBindingList {
-				rbl := rbl
-				if rbl.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Role Bindings: ", rbl.ObjectMeta.Name)
-					_, err := dst.Clientset.RbacV1().RoleBindings(element.ObjectMeta.Name).Create(context.TODO(), &rbl, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create secrets resource
-		if stringInSlice("jobs", dst.Resources) || stringInSlice("job", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Secrets")
-			for _, secret := range src_resources.SecretList {
-				secret := secret
-				if secret.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Secret: ", secret.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().Secrets(element.ObjectMeta.Name).Create(context.TODO(), &secret, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create StorageClass resource
-		if stringInSlice("storageclasses", dst.Resources) || stringInSlice("storageclass", dst.Resources) || stringInSlice("sc", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating StorageClasses")
-			for _, sc := range src_resources.StorageClassList {
-				sc := sc
-				if sc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating StorageClass: ", sc.ObjectMeta.Name)
-					_, err := dst.Clientset.StorageV1().StorageClasses().Create(context.TODO(), &sc, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create CronJob resource
-		if stringInSlice("cronjobs", dst.Resources) || stringInSlice("cronjob", dst.Resources) || stringInSlice("cj", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating CronJob's")
-			for _, cronjob := range src_resources.CronJobList {		
-				cronjob := cronjob		
-				if cronjob.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating CronJob: ", cronjob.ObjectMeta.Name)
-					_, err := dst.Clientset.BatchV1beta1().CronJobs(element.ObjectMeta.Name).Create(context.TODO(), &cronjob, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create job resource
-		if stringInSlice("job", dst.Resources) || stringInSlice("jobs", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Job's")
-			for _, job := range src_resources.JobList {			
-				job := job	
-				if job.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Job's: ", job.ObjectMeta.Name)
-					_, err := dst.Clientset.BatchV1().Jobs(element.ObjectMeta.Name).Create(context.TODO(), &job, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Cluster Cluster Role resource
-		if stringInSlice("clusterrole", dst.Resources) || stringInSlice("clusterroles", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Cluster Roles")
-			for _, crl := range src_resources.ClusterRoleList {
-				crl := crl			
-				if crl.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Role Bindings: ", crl.ObjectMeta.Name)
-					_, err := dst.Clientset.RbacV1().ClusterRoles().Create(context.TODO(), &crl, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Cluster Role Binding resource
-		if stringInSlice("clusterrolebinding", dst.Resources) || stringInSlice("clusterrolebindings", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Cluster Role Bindings")
-			for _, crbl := range src_resources.ClusterRoleBindingList {
-				crbl := crbl
-				if crbl.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Role Bindings: ", crbl.ObjectMeta.Name)
-					_, err := dst.Clientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), &crbl, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
+	// Create the resources of the namespaces, several namespaces at a time
+	Deploy_namespaces(dst, src_resources)
 
-		// Create Hpa resource
-		if stringInSlice("horizontalpodautoscaler", dst.Resources) || stringInSlice("horizontalpodautoscalers", dst.Resources) || stringInSlice("hpa", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating HorizontalPodAutoscalers")
-			for _, hpa := range src_resources.HpaList {
-				hpa := hpa
-				if hpa.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating HorizontalPodAutoscaler: ", hpa.ObjectMeta.Name)
-					_, err := dst.Clientset.AutoscalingV1().HorizontalPodAutoscalers(element.ObjectMeta.Name).Create(context.TODO(), &hpa, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Pod Security Policy resource
-		if stringInSlice("podsecuritypolicies", dst.Resources) || stringInSlice("podsecuritypolicy", dst.Resources) || stringInSlice("psp", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Pod security policies")
-			for _, psp := range src_resources.PspList {
-				psp := psp
-				if psp.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Pod Security Policies: ", psp.ObjectMeta.Name)
-					_, err := dst.Clientset.PolicyV1beta1().PodSecurityPolicies().Create(context.TODO(), &psp, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-
-		// Create Service Account resource
-		if stringInSlice("serviceaccount", dst.Resources) || stringInSlice("serviceaccounts", dst.Resources) || stringInSlice("sa", dst.Resources) || stringInSlice("all", dst.Resources) {
-			fmt.Println("===============")
-			fmt.Println("Creating Service Account Job's")
-			for _, sa := range src_resources.SvcAccList {
-				sa := sa
-				if sa.ObjectMeta.Namespace == element.ObjectMeta.Name {
-					fmt.Println("Creating Service Account: ", sa.ObjectMeta.Name)
-					_, err := dst.Clientset.CoreV1().ServiceAccounts(element.ObjectMeta.Name).Create(context.TODO(), &sa, metav1.CreateOptions{})
-					if err != nil {
-						fmt.Println(err)
-					}
-				}
-			}
-		}
-	}
-}
-
-func Delete_resource_eks(dst *cluster.Cluster, src_resources *resource.Resources) {
-
-	// Loop through each namespace and create resources inside each namespace
-	for _, element := range src_resources.Nsl.Items {
-        element := element
-		fmt.Println("=====================================================================")
-		fmt.Println("Operating on namespace: ", element.ObjectMeta.Name)
-		fmt.Println("=====================================================================")
-		// Delete PVC resource
-		for _, pvc := range src_resources.PersistentVolumeClaimsList {
-			if pvc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting PVC: ", pvc.ObjectMeta.Name)
-				err := dst.Clientset.CoreV1().PersistentVolumeClaims(element.ObjectMeta.Name).Delete(context.TODO(), pvc.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete Deployment resource
-		fmt.Println("Deleting Deployments")
-		for _, dep := range src_resources.Depl {
-			dep := dep
-			if dep.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Secret: ", dep.ObjectMeta.Name)
-				err := dst.Clientset.AppsV1().Deployments(element.ObjectMeta.Name).Delete(context.TODO(), dep.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete Service resource
-		fmt.Println("Deleting Services")
-		for _, svc := range src_resources.Svcl {
-			svc := svc
-			if svc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Service: ", svc.ObjectMeta.Name)
-				err := dst.Clientset.CoreV1().Services(element.ObjectMeta.Name).Delete(context.TODO(), svc.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete DaemonSets resource
-		fmt.Println("Deleting DaemonSets")
-		for _, ds := range src_resources.Dsl {
-		    ds := ds
-			if ds.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting DaemonSet: ", ds.ObjectMeta.Name)
-				err := dst.Clientset.AppsV1().DaemonSets(element.ObjectMeta.Name).Delete(context.TODO(), ds.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete secrets resource
-		fmt.Println("===============")
-		fmt.Println("Deleting Secrets")
-		for _, secret := range src_resources.SecretList {
-			secret := secret
-			if secret.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Secret: ", secret.ObjectMeta.Name)
-				err := dst.Clientset.CoreV1().Secrets(element.ObjectMeta.Name).Delete(context.TODO(), secret.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete StorageClass resource
-		fmt.Println("===============")
-		fmt.Println("Deleting StorageClass")
-		for _, sc := range src_resources.StorageClassList {
-			sc := sc
-			if sc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting StorageClass: ", sc.ObjectMeta.Name)
-				err := dst.Clientset.StorageV1().StorageClasses().Delete(context.TODO(), sc.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		//Create MutatingWebhookConfiguration resource
-		fmt.Println("===============")
-		fmt.Println("Deleting MutatingWebhookConfiguration")
-		for _, mwc := range src_resources.MutatingWebhookConfigurationList {
-			mwc := mwc
-			if mwc.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting MutatingWebhookConfiguration: ", mwc.ObjectMeta.Name)
-				err := dst.Clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Delete(context.TODO(), mwc.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete Configmap resource
-		fmt.Println("===============")
-		fmt.Println("Deleting ConfigMap's")
-		for _, cm := range src_resources.ConfigMapsList {
-			cm := cm
-			if cm.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting ConfigMap: ", cm.ObjectMeta.Name)
-				err := dst.Clientset.CoreV1().ConfigMaps(element.ObjectMeta.Name).Delete(context.TODO(), cm.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete CronJob resource
-		fmt.Println("===============")
-		fmt.Println("Deleting CronJob's")
-		for _, cronjob := range src_resources.CronJobList {
-			cronjob := cronjob
-			if cronjob.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting CronJob: ", cronjob.ObjectMeta.Name)
-				err := dst.Clientset.BatchV1beta1().CronJobs(element.ObjectMeta.Name).Delete(context.TODO(), cronjob.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete Job resource
-		fmt.Println("===============")
-		fmt.Println("Deleting Job's")
-		for _, job := range src_resources.JobList {
-			job := job
-			if job.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Job: ", job.ObjectMeta.Name)
-				err := dst.Clientset.BatchV1().Jobs(element.ObjectMeta.Name).Delete(context.TODO(), job.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete ingress resource
-		fmt.Println("===============")
-		fmt.Println("Deleting Ingresses")
-		for _, ingress := range src_resources.IngressList {
-			ingress := ingress
-			if ingress.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Ingress: ", ingress.ObjectMeta.Name)
-				err := dst.Clientset.NetworkingV1().Ingresses(element.ObjectMeta.Name).Delete(context.TODO(), ingress.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete HPA resource
-		fmt.Println("===============")
-		fmt.Println("Deleting HorizontalPodAutoscalers")
-		for _, hpa := range src_resources.HpaList {
-			hpa := hpa
-			if hpa.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting HorizontalPodAutoscaler: ", hpa.ObjectMeta.Name)
-				err := dst.Clientset.AutoscalingV1().HorizontalPodAutoscalers(element.ObjectMeta.Name).Delete(context.TODO(), hpa.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete psp resources
-		fmt.Println("===============")
-		fmt.Println("Deleting Pod Security Policies")
-		for _, psp := range src_resources.PspList {
-			psp := psp
-			if psp.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting PodSecurityPolicy: ", psp.ObjectMeta.Name)
-				err := dst.Clientset.PolicyV1beta1().PodSecurityPolicies().Delete(context.TODO(), psp.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-		// Delete roles resource
-		fmt.Println("===============")
-		fmt.Println("Deleting Roles")
-		for _, role := range src_resources.RoleList {
-			role := role
-			if role.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Roles: ", role.ObjectMeta.Name)
-				err := dst.Clientset.RbacV1().Roles(element.ObjectMeta.Name).Delete(context.TODO(), role.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-		// Delete service account resources
-		fmt.Println("===============")
-		fmt.Println("Deleting Service Accounts")
-		for _, sa := range src_resources.SvcAccList {
-			sa := sa
-			if sa.ObjectMeta.Namespace == element.ObjectMeta.Name {
-				fmt.Println("Deleting Service Accounts: ", sa.ObjectMeta.Name)
-				err := dst.Clientset.CoreV1().ServiceAccounts(element.ObjectMeta.Name).Delete(context.TODO(), sa.ObjectMeta.Name, metav1.DeleteOptions{})
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-		}
-
-	}
-	// Delete list of namespaces in destination cluster
-	for _, element := range src_resources.Nsl.Items {
-
-		element := element
-		err := dst.Clientset.CoreV1().Namespaces().Delete(context.TODO(), element.ObjectMeta.Name, metav1.DeleteOptions{})
-		if err != nil {
-			fmt.Println(err)
-		}
-	}
+	// Create the webhook configurations once what they call and intercept is there
+	Deploy_webhooks(dst, src_resources)
 }
 
 func Deploy_helm_charts(dst *cluster.Cluster, src_resources *resource.Resources) {
-	args := map[string]string{}
 	//repo 		:= ""
 
 	for namespace, charts := range src_resources.HelmList {
@@ -655,37 +101,56 @@ func Deploy_helm_charts(dst *cluster.Cluster, src_resources *resource.Resources)
 		for key, value := range charts {
 			//name := key
 			//chart := value
+			if dst.GetCheckpoint().Reached("HelmRelease", namespace, key, checkpoint.Created) {
+				fmt.Println("Chart ", key, " already installed by run ", dst.GetRun_id(), " in namespace ", namespace, ", skipped")
+				continue
+			}
 			fmt.Println("Installing Chart ", key, " on EKS cluster in namespace ", namespace)
 			//InstallChart(key,"", value, args, namespace)
-			namespace = namespace
-			args = args
 			// Resolve chart dependency
-			cmd := exec.Command("helm", "dependency", "build")
+			cmd := exec.CommandContext(dst.GetCtx(), "helm", "dependency", "build")
 			cmd.Dir = value
 			out, err := cmd.Output()
 			if err != nil {
+				dst.Exit_if_interrupted()
 				fmt.Println("test2")
 				log.Fatal(err)
 			}
 			
 			fmt.Printf(" %s\n", out)
+			// the revision before the run tells a rollback whether to uninstall the release or roll it back
+			if _, ok := dst.GetCheckpoint().Release(namespace, key); !ok {
+				revision, err := helm_revision(dst, namespace, key)
+				if err != nil {
+					dst.Exit_if_interrupted()
+					fmt.Println("Error reading the status of Helm release ", key, " in namespace ", namespace)
+					log.Fatal(err)
+				}
+				dst.GetCheckpoint().Set_release(namespace, key, revision)
+				dst.GetCheckpoint().Save()
+			}
 			//install charts
-			cmd = exec.Command("helm", "upgrade", "--install", key, "." , "-n", namespace)
+			cmd = helm_command(dst, "upgrade", "--install", key, "." , "-n", namespace)
 			cmd.Dir = value
 			out, err = cmd.Output()
 			if err != nil {
+				dst.Exit_if_interrupted()
 				fmt.Println("Error installing Helm chart. If there is a helm chart already on target cluster with name ", key, " in failed state try deleting and run again")
 				log.Fatal(err)
 			}
 			fmt.Printf(" %s\n", out)
+			dst.GetCheckpoint().Set("HelmRelease", namespace, key, checkpoint.Created)
+			dst.GetCheckpoint().Save()
 
 		}
 	}
 }
 
 func Delete_helm_charts(dst *cluster.Cluster, src_resources *resource.Resources) {
-	args := map[string]string{}
 	//repo 		:= ""
+	if !helm_release.Selected(dst.Resources) {
+		return
+	}
 
 	for namespace, charts := range src_resources.HelmList {
 
@@ -694,16 +159,17 @@ func Delete_helm_charts(dst *cluster.Cluster, src_resources *resource.Resources)
 			//chart := value
 			fmt.Println("Uninstalling Chart ", key, " on EKS cluster")
 			//InstallChart(key,"", value, args, namespace)
-			namespace = namespace
-			args = args
 			//value = value
 
 			//install charts
-			cmd := exec.Command("helm", "uninstall", key, "-n", namespace)
+			cmd := helm_command(dst, "uninstall", key, "-n", namespace)
 			cmd.Dir = value
 			out, err := cmd.Output()
 			if err != nil {
+				dst.Exit_if_interrupted()
 				fmt.Println("Failed uninstalling chart ", key, " but continuing")
+			} else {
+				dst.GetCheckpoint().Set("HelmRelease", namespace, key, checkpoint.Deleted)
 			}
 			fmt.Printf(" %s\n", out)
 
@@ -0,0 +1,76 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Labels and annotations identifying the objects created by KMF
+const (
+	Migrated_by_label         = "migrated-by"
+	Migrated_by_value         = "kmf"
+	Run_id_label              = "kmf.io/run-id"
+	Source_cluster_annotation = "kmf.io/source-cluster"
+	Source_claim_annotation   = "kmf.io/source-claim" // <namespace>/<name> of the claim a PersistentVolumeClaim was copied from
+)
+
+// Add labels and annotations to every scanned object and to the pod templates of workloads,
+// existing selectors are left untouched so services keep selecting the same pods
+func Inject_labels(resources *resource.Resources, labels map[string]string, annotations map[string]string) {
+	if len(labels) == 0 && len(annotations) == 0 {
+		return
+	}
+	fmt.Println("Injecting labels....start")
+
+	resources.Each(func(kind string, obj resource.Object) {
+		obj.SetLabels(merge_map(obj.GetLabels(), labels))
+		obj.SetAnnotations(merge_map(obj.GetAnnotations(), annotations))
+
+		if kind == "CronJob" {
+			jobTemplate := &obj.(*batchv1beta1.CronJob).Spec.JobTemplate.ObjectMeta
+			jobTemplate.Labels = merge_map(jobTemplate.Labels, labels)
+			jobTemplate.Annotations = merge_map(jobTemplate.Annotations, annotations)
+		}
+		if template := resource.Pod_template(obj); template != nil {
+			template.ObjectMeta.Labels = merge_map(template.ObjectMeta.Labels, labels)
+			template.ObjectMeta.Annotations = merge_map(template.ObjectMeta.Annotations, annotations)
+		}
+	})
+
+	fmt.Println("Injecting labels....End")
+}
+
+// Return base with the entries of extra added, base is allocated when nil
+func merge_map(base map[string]string, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return base
+	}
+	if base == nil {
+		base = make(map[string]string)
+	}
+	for key, value := range extra {
+		base[key] = value
+	}
+	return base
+}
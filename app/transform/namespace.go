@@ -0,0 +1,234 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Label set by the API server on every namespace with the namespace name
+const namespace_name_label = "kubernetes.io/metadata.name"
+
+// Move the scanned objects from the source namespaces to the destination namespaces given by mapping (source: destination).
+// References to namespaces held by objects are updated as well so the migrated objects keep working together.
+func Remap_namespaces(resources *resource.Resources, mapping map[string]string) {
+	if len(mapping) == 0 {
+		return
+	}
+	fmt.Println("Remapping namespaces....start")
+
+	resources.Each(func(kind string, obj resource.Object) {
+		if kind == "Namespace" {
+			if target, ok := mapping[obj.GetName()]; ok {
+				fmt.Println("Namespace", obj.GetName(), "will be migrated as", target)
+				obj.SetName(target)
+				labels := obj.GetLabels()
+				delete(labels, namespace_name_label)
+				obj.SetLabels(labels)
+			}
+			return
+		}
+		if target, ok := mapping[obj.GetNamespace()]; ok {
+			obj.SetNamespace(target)
+		}
+
+		switch o := obj.(type) {
+		case *rbac.RoleBinding:
+			remap_subjects(o.Subjects, mapping)
+		case *rbac.ClusterRoleBinding:
+			remap_subjects(o.Subjects, mapping)
+		case *admissionregistration.MutatingWebhookConfiguration:
+			for i := range o.Webhooks {
+				remap_webhook(&o.Webhooks[i].ClientConfig, o.Webhooks[i].NamespaceSelector, mapping)
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			for i := range o.Webhooks {
+				remap_webhook(&o.Webhooks[i].ClientConfig, o.Webhooks[i].NamespaceSelector, mapping)
+			}
+		case *v1.Service:
+			if o.Spec.Type == v1.ServiceTypeExternalName {
+				o.Spec.ExternalName = remap_service_host(o.Spec.ExternalName, mapping)
+			}
+		case *networking.Ingress:
+			remap_ingress(o, mapping)
+		}
+	})
+
+	dedupe_namespaces(resources)
+	resources.HelmList = remap_releases(resources, mapping)
+
+	fmt.Println("Remapping namespaces....End")
+}
+
+// Namespaces mapped to the same destination are created once, the first one in scan order is kept
+func dedupe_namespaces(resources *resource.Resources) {
+	if resources.Nsl == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	items := resources.Nsl.Items[:0]
+	for _, ns := range resources.Nsl.Items {
+		if seen[ns.ObjectMeta.Name] {
+			continue
+		}
+		seen[ns.ObjectMeta.Name] = true
+		items = append(items, ns)
+	}
+	resources.Nsl.Items = items
+}
+
+// Helm releases are installed into the namespace used as key. The charts of namespaces mapped to the same destination
+// are merged, a release whose name is already taken in the destination namespace is left out and reported.
+func remap_releases(resources *resource.Resources, mapping map[string]string) map[string]map[string]string {
+	if resources.HelmList == nil {
+		return nil
+	}
+	namespaces := make([]string, 0, len(resources.HelmList))
+	for namespace := range resources.HelmList {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	remapped := make(map[string]map[string]string)
+	owner := make(map[string]string) // destination namespace/release: source namespace
+	for _, namespace := range namespaces {
+		target, ok := mapping[namespace]
+		if !ok {
+			target = namespace
+		}
+		if remapped[target] == nil {
+			remapped[target] = make(map[string]string)
+		}
+		for release, chart := range resources.HelmList[namespace] {
+			if source, taken := owner[target+"/"+release]; taken {
+				message := fmt.Sprintf("release name already used in namespace %s by the release of source namespace %s, not migrated", target, source)
+				fmt.Println("Helm release", release, "in namespace", namespace, ":", message)
+				resources.Report.Add("namespace", "HelmRelease", namespace, release, message)
+				continue
+			}
+			owner[target+"/"+release] = namespace
+			remapped[target][release] = chart
+		}
+	}
+	return remapped
+}
+
+// Service accounts are referenced by namespace and name, or through their user and group names
+func remap_subjects(subjects []rbac.Subject, mapping map[string]string) {
+	for i, subject := range subjects {
+		switch subject.Kind {
+		case rbac.ServiceAccountKind:
+			if target, ok := mapping[subject.Namespace]; ok {
+				subjects[i].Namespace = target
+			}
+		case rbac.UserKind:
+			// system:serviceaccount:<namespace>:<name>
+			parts := strings.Split(subject.Name, ":")
+			if len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+				if target, ok := mapping[parts[2]]; ok {
+					parts[2] = target
+					subjects[i].Name = strings.Join(parts, ":")
+				}
+			}
+		case rbac.GroupKind:
+			// system:serviceaccounts:<namespace>
+			if strings.HasPrefix(subject.Name, "system:serviceaccounts:") {
+				if target, ok := mapping[strings.TrimPrefix(subject.Name, "system:serviceaccounts:")]; ok {
+					subjects[i].Name = "system:serviceaccounts:" + target
+				}
+			}
+		}
+	}
+}
+
+// Webhooks call a service in a namespace and can be scoped to namespaces by name
+func remap_webhook(clientConfig *admissionregistration.WebhookClientConfig, namespaceSelector *metav1.LabelSelector, mapping map[string]string) {
+	if clientConfig.Service != nil {
+		if target, ok := mapping[clientConfig.Service.Namespace]; ok {
+			clientConfig.Service.Namespace = target
+		}
+	}
+	if namespaceSelector == nil {
+		return
+	}
+	if target, ok := mapping[namespaceSelector.MatchLabels[namespace_name_label]]; ok {
+		namespaceSelector.MatchLabels[namespace_name_label] = target
+	}
+	for i, expression := range namespaceSelector.MatchExpressions {
+		if expression.Key != namespace_name_label {
+			continue
+		}
+		for j, value := range expression.Values {
+			if target, ok := mapping[value]; ok {
+				namespaceSelector.MatchExpressions[i].Values[j] = target
+			}
+		}
+	}
+}
+
+// Ingress backends live in the namespace of the ingress, other namespaces can only be reached through
+// in-cluster service names used by controller annotations such as external auth urls
+func remap_ingress(ingress *networking.Ingress, mapping map[string]string) {
+	for key, value := range ingress.ObjectMeta.Annotations {
+		ingress.ObjectMeta.Annotations[key] = remap_service_hosts(value, mapping)
+	}
+}
+
+// Rewrite every in-cluster service name (<service>.<namespace>.svc[.cluster.local]) found in value
+func remap_service_hosts(value string, mapping map[string]string) string {
+	if !strings.Contains(value, ".svc") {
+		return value
+	}
+	// every field is rewritten once in place so a host already rewritten is not matched again
+	var remapped strings.Builder
+	start := 0
+	for i, r := range value + "/" {
+		if !strings.ContainsRune("/:@,; \"'", r) {
+			continue
+		}
+		remapped.WriteString(remap_service_host(value[start:i], mapping))
+		if i < len(value) {
+			remapped.WriteRune(r)
+		}
+		start = i + 1
+	}
+	return remapped.String()
+}
+
+// Rewrite an in-cluster service name <service>.<namespace>.svc[.cluster.local]
+func remap_service_host(host string, mapping map[string]string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 || labels[2] != "svc" {
+		return host
+	}
+	if target, ok := mapping[labels[1]]; ok {
+		labels[1] = target
+	}
+	return strings.Join(labels, ".")
+}
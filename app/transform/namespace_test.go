@@ -0,0 +1,144 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	app "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+)
+
+func TestRemapNamespaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		mapping        map[string]string
+		wantNamespaces []string
+		wantWorkloads  map[string]string // deployment: namespace
+		wantReleases   map[string]map[string]string
+		wantReported   int
+	}{
+		{
+			name:           "chain",
+			mapping:        map[string]string{"a": "b", "b": "c"},
+			wantNamespaces: []string{"b", "c"},
+			wantWorkloads:  map[string]string{"web-a": "b", "web-b": "c"},
+			wantReleases: map[string]map[string]string{
+				"b": {"shared": "charts/a/shared", "only-a": "charts/a/only-a"},
+				"c": {"shared": "charts/b/shared"},
+			},
+		},
+		{
+			name:           "swap",
+			mapping:        map[string]string{"a": "b", "b": "a"},
+			wantNamespaces: []string{"b", "a"},
+			wantWorkloads:  map[string]string{"web-a": "b", "web-b": "a"},
+			wantReleases: map[string]map[string]string{
+				"b": {"shared": "charts/a/shared", "only-a": "charts/a/only-a"},
+				"a": {"shared": "charts/b/shared"},
+			},
+		},
+		{
+			name:           "merge",
+			mapping:        map[string]string{"a": "c", "b": "c"},
+			wantNamespaces: []string{"c"},
+			wantWorkloads:  map[string]string{"web-a": "c", "web-b": "c"},
+			wantReleases: map[string]map[string]string{
+				"c": {"shared": "charts/a/shared", "only-a": "charts/a/only-a"},
+			},
+			wantReported: 1,
+		},
+		{
+			name:           "merge into an unmapped namespace",
+			mapping:        map[string]string{"b": "a"},
+			wantNamespaces: []string{"a"},
+			wantWorkloads:  map[string]string{"web-a": "a", "web-b": "a"},
+			wantReleases: map[string]map[string]string{
+				"a": {"shared": "charts/a/shared", "only-a": "charts/a/only-a"},
+			},
+			wantReported: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := &resource.Resources{
+				Nsl: &v1.NamespaceList{Items: []v1.Namespace{
+					{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{namespace_name_label: "a"}}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{namespace_name_label: "b"}}},
+				}},
+				Depl: []app.Deployment{
+					{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "a"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "b"}},
+				},
+				HelmList: map[string]map[string]string{
+					"a": {"shared": "charts/a/shared", "only-a": "charts/a/only-a"},
+					"b": {"shared": "charts/b/shared"},
+				},
+				Report: report.New(),
+			}
+
+			Remap_namespaces(resources, tt.mapping)
+
+			var namespaces []string
+			for _, ns := range resources.Nsl.Items {
+				namespaces = append(namespaces, ns.Name)
+				if label, ok := ns.Labels[namespace_name_label]; ok && label != ns.Name {
+					t.Errorf("namespace %s keeps the %s label of namespace %s", ns.Name, namespace_name_label, label)
+				}
+			}
+			if !reflect.DeepEqual(namespaces, tt.wantNamespaces) {
+				t.Errorf("namespaces = %v, want %v", namespaces, tt.wantNamespaces)
+			}
+			for _, deployment := range resources.Depl {
+				if want := tt.wantWorkloads[deployment.Name]; deployment.Namespace != want {
+					t.Errorf("deployment %s is in namespace %s, want %s", deployment.Name, deployment.Namespace, want)
+				}
+			}
+			if !reflect.DeepEqual(resources.HelmList, tt.wantReleases) {
+				t.Errorf("HelmList = %v, want %v", resources.HelmList, tt.wantReleases)
+			}
+			if len(resources.Report.Entries) != tt.wantReported {
+				t.Errorf("report has %d entries, want %d: %v", len(resources.Report.Entries), tt.wantReported, resources.Report.Entries)
+			}
+		})
+	}
+}
+
+func TestRemapServiceHosts(t *testing.T) {
+	swap := map[string]string{"a": "b", "b": "a"}
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"http://auth.a.svc.cluster.local/check", "http://auth.b.svc.cluster.local/check"},
+		{"auth.a.svc:8080,auth.b.svc:8080", "auth.b.svc:8080,auth.a.svc:8080"},
+		{"https://auth.b.svc/a.svc", "https://auth.a.svc/a.svc"},
+		{"no service here", "no service here"},
+	}
+	for _, tt := range tests {
+		if got := remap_service_hosts(tt.value, swap); got != tt.want {
+			t.Errorf("remap_service_hosts(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"fmt"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	app "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Objects created by Kubernetes itself in every namespace, renaming them would only create a copy
+var keep_name = map[string]map[string]bool{
+	"ServiceAccount": {"default": true},
+	"ConfigMap":      {"kube-root-ca.crt": true},
+	"Service":        {"kubernetes": true},
+}
+
+// Add a prefix and/or suffix to the name of every scanned object except namespaces, so the migrated objects
+// can coexist with existing ones. References between the migrated objects are updated to the new names.
+func Rename_objects(resources *resource.Resources, prefix string, suffix string) {
+	if prefix == "" && suffix == "" {
+		return
+	}
+	fmt.Println("Renaming objects....start")
+
+	// kind -> namespace/name -> new name
+	renamed := make(map[string]map[string]string)
+	resources.Each(func(kind string, obj resource.Object) {
+		if kind == "Namespace" || keep_name[kind][obj.GetName()] {
+			return
+		}
+		name := prefix + obj.GetName() + suffix
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 || (kind == "Service" && len(name) > validation.DNS1035LabelMaxLength) {
+			resources.Report.Add("rename", kind, obj.GetNamespace(), obj.GetName(), fmt.Sprintf("name %s is not valid, keeping the original name", name))
+			return
+		}
+		if renamed[kind] == nil {
+			renamed[kind] = make(map[string]string)
+		}
+		renamed[kind][obj.GetNamespace()+"/"+obj.GetName()] = name
+		if sts, ok := obj.(*app.StatefulSet); ok && len(sts.Spec.VolumeClaimTemplates) > 0 {
+			resources.Report.Add("rename", kind, obj.GetNamespace(), obj.GetName(), "the claims of the volumeClaimTemplates are named after the new StatefulSet name, the migrated claims of the source StatefulSet are not used")
+		}
+		obj.SetName(name)
+	})
+
+	// lookup the new name of a referenced object, the name is unchanged when the object was not renamed
+	lookup := func(kind string, namespace string, name string) string {
+		if newName, ok := renamed[kind][namespace+"/"+name]; ok {
+			return newName
+		}
+		return name
+	}
+
+	resources.Each(func(kind string, obj resource.Object) {
+		namespace := obj.GetNamespace()
+		if template := resource.Pod_template(obj); template != nil {
+			rename_pod_spec(&template.Spec, namespace, lookup)
+		}
+
+		switch o := obj.(type) {
+		case *v1.Secret:
+			if sa, ok := o.ObjectMeta.Annotations[v1.ServiceAccountNameKey]; ok {
+				o.ObjectMeta.Annotations[v1.ServiceAccountNameKey] = lookup("ServiceAccount", namespace, sa)
+			}
+		case *v1.ServiceAccount:
+			for i := range o.Secrets {
+				o.Secrets[i].Name = lookup("Secret", namespace, o.Secrets[i].Name)
+			}
+			for i := range o.ImagePullSecrets {
+				o.ImagePullSecrets[i].Name = lookup("Secret", namespace, o.ImagePullSecrets[i].Name)
+			}
+		case *app.StatefulSet:
+			o.Spec.ServiceName = lookup("Service", namespace, o.Spec.ServiceName)
+			for i := range o.Spec.VolumeClaimTemplates {
+				if class := o.Spec.VolumeClaimTemplates[i].Spec.StorageClassName; class != nil {
+					storageClass := lookup("StorageClass", "", *class)
+					o.Spec.VolumeClaimTemplates[i].Spec.StorageClassName = &storageClass
+				}
+			}
+		case *v1.PersistentVolumeClaim:
+			if o.Spec.StorageClassName != nil {
+				storageClass := lookup("StorageClass", "", *o.Spec.StorageClassName)
+				o.Spec.StorageClassName = &storageClass
+			}
+		case *networking.Ingress:
+			rename_ingress_backend(o.Spec.DefaultBackend, namespace, lookup)
+			for i := range o.Spec.Rules {
+				if o.Spec.Rules[i].HTTP == nil {
+					continue
+				}
+				for j := range o.Spec.Rules[i].HTTP.Paths {
+					rename_ingress_backend(&o.Spec.Rules[i].HTTP.Paths[j].Backend, namespace, lookup)
+				}
+			}
+			for i := range o.Spec.TLS {
+				o.Spec.TLS[i].SecretName = lookup("Secret", namespace, o.Spec.TLS[i].SecretName)
+			}
+		case *autoscaling.HorizontalPodAutoscaler:
+			o.Spec.ScaleTargetRef.Name = lookup(o.Spec.ScaleTargetRef.Kind, namespace, o.Spec.ScaleTargetRef.Name)
+		case *rbac.RoleBinding:
+			if o.RoleRef.Kind == "Role" {
+				o.RoleRef.Name = lookup("Role", namespace, o.RoleRef.Name)
+			} else {
+				o.RoleRef.Name = lookup("ClusterRole", "", o.RoleRef.Name)
+			}
+			rename_subjects(o.Subjects, lookup)
+		case *rbac.ClusterRoleBinding:
+			o.RoleRef.Name = lookup("ClusterRole", "", o.RoleRef.Name)
+			rename_subjects(o.Subjects, lookup)
+		case *admissionregistration.MutatingWebhookConfiguration:
+			for i := range o.Webhooks {
+				rename_webhook_service(o.Webhooks[i].ClientConfig.Service, lookup)
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			for i := range o.Webhooks {
+				rename_webhook_service(o.Webhooks[i].ClientConfig.Service, lookup)
+			}
+		}
+	})
+
+	fmt.Println("Renaming objects....End")
+}
+
+// Update the ConfigMaps, Secrets, PersistentVolumeClaims and ServiceAccount referenced by a pod spec
+func rename_pod_spec(spec *v1.PodSpec, namespace string, lookup func(string, string, string) string) {
+	if spec.ServiceAccountName != "" {
+		spec.ServiceAccountName = lookup("ServiceAccount", namespace, spec.ServiceAccountName)
+	}
+	if spec.DeprecatedServiceAccount != "" {
+		spec.DeprecatedServiceAccount = lookup("ServiceAccount", namespace, spec.DeprecatedServiceAccount)
+	}
+	for i := range spec.ImagePullSecrets {
+		spec.ImagePullSecrets[i].Name = lookup("Secret", namespace, spec.ImagePullSecrets[i].Name)
+	}
+
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+		if volume.ConfigMap != nil {
+			volume.ConfigMap.Name = lookup("ConfigMap", namespace, volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			volume.Secret.SecretName = lookup("Secret", namespace, volume.Secret.SecretName)
+		}
+		if volume.PersistentVolumeClaim != nil {
+			volume.PersistentVolumeClaim.ClaimName = lookup("PersistentVolumeClaim", namespace, volume.PersistentVolumeClaim.ClaimName)
+		}
+		if volume.Projected != nil {
+			for j := range volume.Projected.Sources {
+				source := &volume.Projected.Sources[j]
+				if source.ConfigMap != nil {
+					source.ConfigMap.Name = lookup("ConfigMap", namespace, source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					source.Secret.Name = lookup("Secret", namespace, source.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := [][]v1.Container{spec.InitContainers, spec.Containers}
+	for _, list := range containers {
+		for i := range list {
+			container := &list[i]
+			for j := range container.EnvFrom {
+				if container.EnvFrom[j].ConfigMapRef != nil {
+					container.EnvFrom[j].ConfigMapRef.Name = lookup("ConfigMap", namespace, container.EnvFrom[j].ConfigMapRef.Name)
+				}
+				if container.EnvFrom[j].SecretRef != nil {
+					container.EnvFrom[j].SecretRef.Name = lookup("Secret", namespace, container.EnvFrom[j].SecretRef.Name)
+				}
+			}
+			for j := range container.Env {
+				valueFrom := container.Env[j].ValueFrom
+				if valueFrom == nil {
+					continue
+				}
+				if valueFrom.ConfigMapKeyRef != nil {
+					valueFrom.ConfigMapKeyRef.Name = lookup("ConfigMap", namespace, valueFrom.ConfigMapKeyRef.Name)
+				}
+				if valueFrom.SecretKeyRef != nil {
+					valueFrom.SecretKeyRef.Name = lookup("Secret", namespace, valueFrom.SecretKeyRef.Name)
+				}
+			}
+		}
+	}
+}
+
+func rename_ingress_backend(backend *networking.IngressBackend, namespace string, lookup func(string, string, string) string) {
+	if backend != nil && backend.Service != nil {
+		backend.Service.Name = lookup("Service", namespace, backend.Service.Name)
+	}
+}
+
+func rename_subjects(subjects []rbac.Subject, lookup func(string, string, string) string) {
+	for i, subject := range subjects {
+		if subject.Kind == rbac.ServiceAccountKind {
+			subjects[i].Name = lookup("ServiceAccount", subject.Namespace, subject.Name)
+		}
+	}
+}
+
+func rename_webhook_service(service *admissionregistration.ServiceReference, lookup func(string, string, string) string) {
+	if service != nil {
+		service.Name = lookup("Service", service.Namespace, service.Name)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	yaml "github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+// Rules is the content of a transformation rules file
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule applies its actions, in order, to every scanned object selected by its match
+type Rule struct {
+	Name    string   `json:"name"`
+	Match   Match    `json:"match"`
+	Actions []Action `json:"actions"`
+}
+
+// Match selects objects, every field left empty matches all objects
+type Match struct {
+	Kinds         []string `json:"kinds,omitempty"`         // object kinds, e.g. Deployment
+	Namespaces    []string `json:"namespaces,omitempty"`    // namespace name globs, cluster scoped objects never match
+	Name          string   `json:"name,omitempty"`          // object name glob
+	LabelSelector string   `json:"labelSelector,omitempty"` // label selector, e.g. app=web,tier!=db
+}
+
+// Action is one of the supported edits, exactly one field must be set
+type Action struct {
+	JSONPatch      json.RawMessage        `json:"jsonPatch,omitempty"`      // RFC 6902 JSON patch operations
+	StrategicMerge map[string]interface{} `json:"strategicMerge,omitempty"` // Kubernetes strategic merge patch
+	DeleteField    string                 `json:"deleteField,omitempty"`    // path of the field to remove
+	SetField       *SetField              `json:"setField,omitempty"`       // path and value of the field to set
+}
+
+// SetField sets the field at Path to Value, missing parent objects are created
+type SetField struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// Read and validate a transformation rules file
+func Load_rules(rulesFile string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			rules.Rules[i].Name = "rule-" + strconv.Itoa(i+1)
+		}
+		if _, err := labels.Parse(rule.Match.LabelSelector); err != nil {
+			return nil, fmt.Errorf("rule %s: %v", rules.Rules[i].Name, err)
+		}
+		for j, action := range rule.Actions {
+			set := 0
+			for _, isSet := range []bool{len(action.JSONPatch) > 0, action.StrategicMerge != nil, action.DeleteField != "", action.SetField != nil} {
+				if isSet {
+					set++
+				}
+			}
+			if set != 1 {
+				return nil, fmt.Errorf("rule %s: action %d must set exactly one of jsonPatch, strategicMerge, deleteField or setField", rules.Rules[i].Name, j+1)
+			}
+		}
+	}
+	return rules.Rules, nil
+}
+
+// Apply the rules loaded by Load_rules to the scanned objects. With explain set the objects touched by every rule are printed.
+func Apply_rules(resources *resource.Resources, rules []Rule, explain bool) {
+	if len(rules) == 0 {
+		return
+	}
+	fmt.Println("Applying transformation rules....start")
+	touched := apply_rules(resources, rules)
+	if explain {
+		explain_rules(os.Stdout, rules, touched)
+	}
+	fmt.Println("Applying transformation rules....End")
+}
+
+// Apply every matching rule to the scanned objects, returns the objects touched by each rule. The actions of a rule are
+// applied all or nothing, an object a rule fails on is left as it was and the failure is reported.
+func apply_rules(resources *resource.Resources, rules []Rule) map[string][]string {
+	touched := make(map[string][]string)
+	resources.Each(func(kind string, obj resource.Object) {
+		for _, rule := range rules {
+			if !rule.Match.matches(kind, obj) {
+				continue
+			}
+			object := kind + " " + object_name(obj)
+			if err := apply_actions(obj, rule.Actions); err != nil {
+				resources.Report.Add("rules", kind, obj.GetNamespace(), obj.GetName(), fmt.Sprintf("rule %s failed: %v", rule.Name, err))
+				continue
+			}
+			touched[rule.Name] = append(touched[rule.Name], object)
+		}
+	})
+	return touched
+}
+
+// Print the objects touched by every rule, in the order of the rules file
+func explain_rules(w io.Writer, rules []Rule, touched map[string][]string) {
+	fmt.Fprintln(w, "=====================================================================")
+	fmt.Fprintln(w, "Transformation rules explained")
+	fmt.Fprintln(w, "=====================================================================")
+	for _, rule := range rules {
+		fmt.Fprintf(w, "Rule %s touched %d object(s)\n", rule.Name, len(touched[rule.Name]))
+		for _, object := range touched[rule.Name] {
+			fmt.Fprintln(w, "   ", object)
+		}
+	}
+}
+
+func (m Match) matches(kind string, obj resource.Object) bool {
+	if len(m.Kinds) > 0 {
+		found := false
+		for _, k := range m.Kinds {
+			if strings.EqualFold(k, kind) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(m.Namespaces) > 0 {
+		if obj.GetNamespace() == "" {
+			return false
+		}
+		found := false
+		for _, pattern := range m.Namespaces {
+			if ok, _ := path.Match(pattern, obj.GetNamespace()); ok {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if m.Name != "" {
+		if ok, _ := path.Match(m.Name, obj.GetName()); !ok {
+			return false
+		}
+	}
+	if m.LabelSelector != "" {
+		selector, err := labels.Parse(m.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply the actions to the JSON representation of obj and decode the result back into obj
+func apply_actions(obj resource.Object, actions []Action) error {
+	doc, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		switch {
+		case len(action.JSONPatch) > 0:
+			patch, err := jsonpatch.DecodePatch(action.JSONPatch)
+			if err != nil {
+				return err
+			}
+			if doc, err = patch.Apply(doc); err != nil {
+				return err
+			}
+		case action.StrategicMerge != nil:
+			patch, err := json.Marshal(action.StrategicMerge)
+			if err != nil {
+				return err
+			}
+			if doc, err = strategicpatch.StrategicMergePatch(doc, patch, obj); err != nil {
+				return err
+			}
+		case action.DeleteField != "":
+			if doc, err = edit_field(doc, action.DeleteField, nil, true); err != nil {
+				return err
+			}
+		case action.SetField != nil:
+			if doc, err = edit_field(doc, action.SetField.Path, action.SetField.Value, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	// decode into a fresh object so fields removed by the actions do not survive
+	updated := reflect.New(reflect.TypeOf(obj).Elem())
+	if err := json.Unmarshal(doc, updated.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(obj).Elem().Set(updated.Elem())
+	return nil
+}
+
+// Set or delete the field at fieldPath in the JSON document doc. The path is either a JSON pointer
+// (/metadata/annotations/kubernetes.io~1ingress.class) or dot separated (spec.template.spec.nodeSelector)
+// where numeric segments index lists.
+func edit_field(doc []byte, fieldPath string, value interface{}, remove bool) ([]byte, error) {
+	var segments []string
+	if strings.HasPrefix(fieldPath, "/") {
+		for _, segment := range strings.Split(fieldPath[1:], "/") {
+			segments = append(segments, strings.Replace(strings.Replace(segment, "~1", "/", -1), "~0", "~", -1))
+		}
+	} else {
+		segments = strings.Split(fieldPath, ".")
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+
+	// replace is used to swap the current node inside its container when a list element is removed
+	parent := root
+	replace := func(v interface{}) { root = v }
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if last {
+				if remove {
+					delete(node, segment)
+				} else {
+					node[segment] = value
+				}
+				return json.Marshal(root)
+			}
+			child, ok := node[segment]
+			if !ok || child == nil {
+				if remove {
+					// nothing to delete
+					return doc, nil
+				}
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			key := segment
+			replace = func(v interface{}) { node[key] = v }
+			parent = child
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				if remove {
+					return doc, nil
+				}
+				return nil, fmt.Errorf("invalid list index %q in %s", segment, fieldPath)
+			}
+			if last {
+				if remove {
+					replace(append(node[:index], node[index+1:]...))
+				} else {
+					node[index] = value
+				}
+				return json.Marshal(root)
+			}
+			replace = func(v interface{}) { node[index] = v }
+			parent = node[index]
+		default:
+			if remove {
+				return doc, nil
+			}
+			return nil, fmt.Errorf("cannot set %s, %s is not an object", fieldPath, strings.Join(segments[:i], "."))
+		}
+	}
+	return json.Marshal(root)
+}
+
+// Return namespace/name or name for cluster scoped objects
+func object_name(obj resource.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
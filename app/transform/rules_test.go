@@ -0,0 +1,186 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	app "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+)
+
+func TestMatch(t *testing.T) {
+	web := &app.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-front", Namespace: "team-a", Labels: map[string]string{"tier": "frontend"}}}
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	tests := []struct {
+		name  string
+		match Match
+		kind  string
+		obj   resource.Object
+		want  bool
+	}{
+		{"empty matches all", Match{}, "Deployment", web, true},
+		{"kind", Match{Kinds: []string{"Deployment"}}, "Deployment", web, true},
+		{"kind case insensitive", Match{Kinds: []string{"deployment"}}, "Deployment", web, true},
+		{"other kind", Match{Kinds: []string{"DaemonSet", "Job"}}, "Deployment", web, false},
+		{"namespace glob", Match{Namespaces: []string{"team-*"}}, "Deployment", web, true},
+		{"other namespace", Match{Namespaces: []string{"prod"}}, "Deployment", web, false},
+		{"cluster scoped never matches a namespace", Match{Namespaces: []string{"*"}}, "Namespace", namespace, false},
+		{"name glob", Match{Name: "web-*"}, "Deployment", web, true},
+		{"other name", Match{Name: "api-*"}, "Deployment", web, false},
+		{"label selector", Match{LabelSelector: "tier=frontend"}, "Deployment", web, true},
+		{"label selector not matching", Match{LabelSelector: "tier!=frontend"}, "Deployment", web, false},
+		{"all fields", Match{Kinds: []string{"Deployment"}, Namespaces: []string{"team-*"}, Name: "web-*", LabelSelector: "tier"}, "Deployment", web, true},
+		{"all fields but one", Match{Kinds: []string{"Deployment"}, Namespaces: []string{"team-*"}, Name: "web-*", LabelSelector: "app"}, "Deployment", web, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.matches(tt.kind, tt.obj); got != tt.want {
+				t.Errorf("matches(%s %s) = %v, want %v", tt.kind, tt.obj.GetName(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyActions(t *testing.T) {
+	replicas := int32(1)
+	deployment := func() *app.Deployment {
+		return &app.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Annotations: map[string]string{"owner": "gke"}},
+			Spec: app.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{
+					NodeSelector: map[string]string{"cloud.google.com/gke-nodepool": "pool-1"},
+					Containers:   []v1.Container{{Name: "web", Image: "web:1"}},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		actions []Action
+		wantErr bool
+		check   func(*app.Deployment) bool
+	}{
+		{
+			name:    "json patch",
+			actions: []Action{{JSONPatch: json.RawMessage(`[{"op":"replace","path":"/spec/replicas","value":3}]`)}},
+			check:   func(d *app.Deployment) bool { return *d.Spec.Replicas == 3 },
+		},
+		{
+			name:    "strategic merge keeps the other containers fields",
+			actions: []Action{{StrategicMerge: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web", "env": []interface{}{map[string]interface{}{"name": "CLOUD", "value": "aws"}}}}}}}}}},
+			check: func(d *app.Deployment) bool {
+				c := d.Spec.Template.Spec.Containers
+				return len(c) == 1 && c[0].Image == "web:1" && len(c[0].Env) == 1 && c[0].Env[0].Value == "aws"
+			},
+		},
+		{
+			name:    "delete field",
+			actions: []Action{{DeleteField: "spec.template.spec.nodeSelector"}},
+			check:   func(d *app.Deployment) bool { return d.Spec.Template.Spec.NodeSelector == nil },
+		},
+		{
+			name:    "set field with a JSON pointer",
+			actions: []Action{{SetField: &SetField{Path: "/metadata/labels/example.com~1owner", Value: "platform"}}},
+			check:   func(d *app.Deployment) bool { return d.Labels["example.com/owner"] == "platform" },
+		},
+		{
+			name: "actions apply in order",
+			actions: []Action{
+				{SetField: &SetField{Path: "metadata.annotations.owner", Value: "aws"}},
+				{JSONPatch: json.RawMessage(`[{"op":"test","path":"/metadata/annotations/owner","value":"aws"}]`)},
+			},
+			check: func(d *app.Deployment) bool { return d.Annotations["owner"] == "aws" },
+		},
+		{
+			name: "failing action leaves the object untouched",
+			actions: []Action{
+				{DeleteField: "spec.template.spec.nodeSelector"},
+				{SetField: &SetField{Path: "metadata.annotations.owner", Value: "aws"}},
+				{JSONPatch: json.RawMessage(`[{"op":"remove","path":"/spec/missing"}]`)},
+			},
+			wantErr: true,
+			check:   func(d *app.Deployment) bool { return reflect.DeepEqual(d, deployment()) },
+		},
+		{
+			name:    "set field through a value fails",
+			actions: []Action{{SetField: &SetField{Path: "metadata.name.first", Value: "x"}}},
+			wantErr: true,
+			check:   func(d *app.Deployment) bool { return reflect.DeepEqual(d, deployment()) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := deployment()
+			err := apply_actions(obj, tt.actions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply_actions returned %v, want an error: %v", err, tt.wantErr)
+			}
+			if !tt.check(obj) {
+				t.Errorf("unexpected object after apply_actions: %+v", obj)
+			}
+		})
+	}
+}
+
+func TestExplainRules(t *testing.T) {
+	resources := &resource.Resources{
+		Nsl: &v1.NamespaceList{Items: []v1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}}},
+		Depl: []app.Deployment{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"}},
+		},
+		Report: report.New(),
+	}
+	rules := []Rule{
+		{Name: "label-web", Match: Match{Kinds: []string{"Deployment"}, Name: "web"}, Actions: []Action{{SetField: &SetField{Path: "metadata.labels.tier", Value: "frontend"}}}},
+		{Name: "broken", Match: Match{Kinds: []string{"Deployment"}, Name: "api"}, Actions: []Action{{JSONPatch: json.RawMessage(`[{"op":"remove","path":"/spec/missing"}]`)}}},
+		{Name: "unused", Match: Match{Kinds: []string{"DaemonSet"}}, Actions: []Action{{DeleteField: "spec"}}},
+	}
+
+	touched := apply_rules(resources, rules)
+	var out bytes.Buffer
+	explain_rules(&out, rules, touched)
+
+	want := []string{
+		"Rule label-web touched 1 object(s)",
+		"    Deployment shop/web",
+		"Rule broken touched 0 object(s)",
+		"Rule unused touched 0 object(s)",
+	}
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")[3:]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("explain output = %q, want %q", got, want)
+	}
+	if resources.Depl[0].Labels["tier"] != "frontend" {
+		t.Errorf("rule label-web was not applied: %v", resources.Depl[0].Labels)
+	}
+	if len(resources.Report.Entries) != 1 || resources.Report.Entries[0].Name != "api" {
+		t.Errorf("report = %v, want the failure of rule broken on api", resources.Report.Entries)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	yaml "github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+
+	resource "containers-migration-factory/app/resource"
+)
+
+//go:embed storageclass-mapping.yaml
+var default_storage_class_mapping []byte
+
+// StorageClassMapping is the content of a StorageClass mapping file
+type StorageClassMapping struct {
+	StorageClasses []StorageClassRule `json:"storageClasses"`
+	Names          map[string]string  `json:"names,omitempty"` // old StorageClass name to new name
+}
+
+// StorageClassRule replaces the provisioner and parameters of the StorageClasses selected by its match
+type StorageClassRule struct {
+	Match       StorageClassMatch `json:"match"`
+	Provisioner string            `json:"provisioner"`
+	Parameters  map[string]string `json:"parameters,omitempty"`
+	KeepDefault bool              `json:"keepDefault,omitempty"` // keep the default class annotation of the source class
+}
+
+// StorageClassMatch selects StorageClasses by provisioner and parameter value globs
+type StorageClassMatch struct {
+	Provisioners []string          `json:"provisioners"`
+	Parameters   map[string]string `json:"parameters,omitempty"`
+}
+
+// Parameters understood by every CSI driver, they are kept when the provisioner is replaced
+var kept_parameters = []string{"csi.storage.k8s.io/fstype"}
+
+// Annotations making a StorageClass the default one, EKS already has its own default class
+var default_class_annotations = []string{
+	"storageclass.kubernetes.io/is-default-class",
+	"storageclass.beta.kubernetes.io/is-default-class",
+}
+
+// Annotation used for the StorageClass of a claim before storageClassName existed
+const beta_storage_class_annotation = "volume.beta.kubernetes.io/storage-class"
+
+// Annotations binding a claim to a volume of the source cluster
+var pvc_binding_annotations = []string{
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+// Load a StorageClass mapping file, the built-in mapping is used when no file is given
+func Load_storage_class_mapping(mappingFile string) (*StorageClassMapping, error) {
+	data := default_storage_class_mapping
+	if mappingFile != "" {
+		var err error
+		if data, err = ioutil.ReadFile(mappingFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var mapping StorageClassMapping
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, err
+	}
+	for i, rule := range mapping.StorageClasses {
+		if len(rule.Match.Provisioners) == 0 || rule.Provisioner == "" {
+			return nil, fmt.Errorf("storage class mapping %d needs match.provisioners and provisioner", i+1)
+		}
+		for _, pattern := range rule.Match.Parameters {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("storage class mapping %d: invalid parameter pattern %q", i+1, pattern)
+			}
+		}
+	}
+	return &mapping, nil
+}
+
+// Translate the StorageClasses of the source cloud to EBS and EFS CSI classes and point the PersistentVolumeClaims at the new names
+func Map_storage_classes(resources *resource.Resources, mappingFile string) {
+	fmt.Println("Mapping storage classes....start")
+	mapping, err := Load_storage_class_mapping(mappingFile)
+	if err != nil {
+		fmt.Printf("Error loading storage class mapping %s: %v\n", mappingFile, err)
+		os.Exit(1)
+	}
+
+	for i := range resources.StorageClassList {
+		sc := &resources.StorageClassList[i]
+		if rule := mapping.match(sc); rule != nil {
+			map_storage_class(resources, sc, rule)
+		}
+		if name, ok := mapping.Names[sc.ObjectMeta.Name]; ok {
+			sc.ObjectMeta.Name = name
+		}
+	}
+
+	for i := range resources.PersistentVolumeClaimsList {
+		map_claim(&resources.PersistentVolumeClaimsList[i], mapping.Names)
+	}
+	for i := range resources.StatefulSetList {
+		templates := resources.StatefulSetList[i].Spec.VolumeClaimTemplates
+		for j := range templates {
+			if templates[j].Spec.StorageClassName == nil {
+				continue
+			}
+			if name, ok := mapping.Names[*templates[j].Spec.StorageClassName]; ok {
+				templates[j].Spec.StorageClassName = &name
+			}
+		}
+	}
+	fmt.Println("Mapping storage classes....End")
+}
+
+// First rule matching the provisioner and parameters of a StorageClass
+func (m *StorageClassMapping) match(sc *storage.StorageClass) *StorageClassRule {
+	for i := range m.StorageClasses {
+		if m.StorageClasses[i].Match.matches(sc) {
+			return &m.StorageClasses[i]
+		}
+	}
+	return nil
+}
+
+func (m StorageClassMatch) matches(sc *storage.StorageClass) bool {
+	provisioner := false
+	for _, name := range m.Provisioners {
+		if name == sc.Provisioner {
+			provisioner = true
+		}
+	}
+	if !provisioner {
+		return false
+	}
+	for key, pattern := range m.Parameters {
+		if ok, _ := path.Match(pattern, sc.Parameters[key]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func map_storage_class(resources *resource.Resources, sc *storage.StorageClass, rule *StorageClassRule) {
+	parameters := make(map[string]string)
+	for _, key := range kept_parameters {
+		if value, ok := sc.Parameters[key]; ok {
+			parameters[key] = value
+		}
+	}
+	// the in-tree drivers take the filesystem type without the CSI prefix
+	if value, ok := sc.Parameters["fsType"]; ok && rule.Provisioner != "efs.csi.aws.com" {
+		parameters["csi.storage.k8s.io/fstype"] = value
+	}
+	if rule.Provisioner == "efs.csi.aws.com" {
+		delete(parameters, "csi.storage.k8s.io/fstype")
+	}
+	for key, value := range rule.Parameters {
+		parameters[key] = value
+		if value == "REPLACE-ME" {
+			resources.Report.Add("storage", "StorageClass", "", sc.ObjectMeta.Name, fmt.Sprintf("set parameter %s of the %s class before deploying", key, rule.Provisioner))
+		}
+	}
+
+	if !rule.KeepDefault {
+		for _, key := range default_class_annotations {
+			if sc.ObjectMeta.Annotations[key] == "true" {
+				resources.Report.Add("storage", "StorageClass", "", sc.ObjectMeta.Name, "no longer the default class, claims without a storageClassName use the default class of the destination cluster")
+			}
+			delete(sc.ObjectMeta.Annotations, key)
+		}
+	}
+
+	fmt.Printf("StorageClass %s: %s -> %s\n", sc.ObjectMeta.Name, sc.Provisioner, rule.Provisioner)
+	sc.Provisioner = rule.Provisioner
+	sc.Parameters = parameters
+}
+
+// Point a claim at the renamed StorageClass and release it from the volume it was bound to in the source cluster
+func map_claim(pvc *v1.PersistentVolumeClaim, names map[string]string) {
+	if pvc.Spec.StorageClassName != nil {
+		if name, ok := names[*pvc.Spec.StorageClassName]; ok {
+			pvc.Spec.StorageClassName = &name
+		}
+	}
+	if class, ok := pvc.ObjectMeta.Annotations[beta_storage_class_annotation]; ok {
+		if name, ok := names[class]; ok {
+			pvc.ObjectMeta.Annotations[beta_storage_class_annotation] = name
+		}
+	}
+
+	pvc.Spec.VolumeName = ""
+	for _, key := range pvc_binding_annotations {
+		delete(pvc.ObjectMeta.Annotations, key)
+	}
+
+	// namespace and name change later on, remember the source claim for the data copy
+	if pvc.ObjectMeta.Annotations == nil {
+		pvc.ObjectMeta.Annotations = make(map[string]string)
+	}
+	pvc.ObjectMeta.Annotations[Source_claim_annotation] = pvc.ObjectMeta.Namespace + "/" + pvc.ObjectMeta.Name
+}
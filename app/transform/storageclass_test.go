@@ -0,0 +1,60 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transform
+
+import (
+	"testing"
+
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	report "containers-migration-factory/app/report"
+	resource "containers-migration-factory/app/resource"
+)
+
+func TestMapStorageClassDefault(t *testing.T) {
+	tests := []struct {
+		name         string
+		keepDefault  bool
+		wantDefault  bool
+		wantReported int
+	}{
+		{name: "stripped", wantReported: 1},
+		{name: "kept", keepDefault: true, wantDefault: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := &resource.Resources{Report: report.New()}
+			sc := &storage.StorageClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "standard-rwo", Annotations: map[string]string{default_class_annotations[0]: "true"}},
+				Provisioner: "pd.csi.storage.gke.io",
+			}
+			rule := &StorageClassRule{Provisioner: "ebs.csi.aws.com", KeepDefault: tt.keepDefault}
+
+			map_storage_class(resources, sc, rule)
+
+			if _, ok := sc.ObjectMeta.Annotations[default_class_annotations[0]]; ok != tt.wantDefault {
+				t.Errorf("default class annotation kept = %v, want %v", ok, tt.wantDefault)
+			}
+			if len(resources.Report.Entries) != tt.wantReported {
+				t.Errorf("report has %d entries, want %d", len(resources.Report.Entries), tt.wantReported)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package volume
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+
+	cluster "containers-migration-factory/app/cluster"
+)
+
+const progress_interval = 5 * time.Second
+
+// Run a command in the transfer container of a pod, streaming stdin and stdout
+func exec_in_pod(c *cluster.Cluster, pod *v1.Pod, command []string, stdin io.Reader, stdout io.Writer) error {
+	req := c.GetClientset().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.ObjectMeta.Namespace).
+		Name(pod.ObjectMeta.Name).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: container_name,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(c.GetRest_config(), "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err = executor.Stream(remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: &stderr})
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// sha256 of every regular file under /data, keyed by path relative to /data
+func checksums(c *cluster.Cluster, pod *v1.Pod) (map[string]string, error) {
+	var out bytes.Buffer
+	if err := exec_in_pod(c, pod, []string{"sh", "-c", "cd " + mount_path + " && find . -type f -exec sha256sum {} +"}, nil, &out); err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// <64 hex digits><two spaces><path>
+		line := scanner.Text()
+		if len(line) < 67 {
+			continue
+		}
+		sums[line[66:]] = line[:64]
+	}
+	return sums, scanner.Err()
+}
+
+// Size in bytes of every regular file under /data, used to show the progress of the copy
+func file_sizes(c *cluster.Cluster, pod *v1.Pod) (map[string]int64, error) {
+	var out bytes.Buffer
+	if err := exec_in_pod(c, pod, []string{"sh", "-c", "cd " + mount_path + " && find . -type f -exec stat -c '%s %n' {} +"}, nil, &out); err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64)
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[fields[1]] = size
+	}
+	return sizes, scanner.Err()
+}
+
+// Stream a tar of the files from the source pod to the destination pod through this process
+func copy_files(src *cluster.Cluster, srcPod *v1.Pod, dst *cluster.Cluster, dstPod *v1.Pod, files []string, all bool, total int64, name string) error {
+	create := []string{"tar", "-C", mount_path, "-cf", "-", "-T", "-"}
+	var list io.Reader = strings.NewReader(strings.Join(files, "\n") + "\n")
+	if all {
+		create = []string{"tar", "-C", mount_path, "-cf", "-", "."}
+		list = nil
+	}
+
+	reader, writer := io.Pipe()
+	srcDone := make(chan error, 1)
+	go func() {
+		err := exec_in_pod(src, srcPod, create, list, writer)
+		writer.CloseWithError(err)
+		srcDone <- err
+	}()
+
+	progress := &progress_reader{reader: reader}
+	stop := progress.report(name, total)
+	err := exec_in_pod(dst, dstPod, []string{"tar", "-C", mount_path, "-xf", "-"}, progress, io.Discard)
+	stop()
+	if err != nil {
+		// unblock the source side if the destination gave up first
+		reader.CloseWithError(err)
+		<-srcDone
+		return fmt.Errorf("destination: %v", err)
+	}
+	if err := <-srcDone; err != nil {
+		return fmt.Errorf("source: %v", err)
+	}
+	fmt.Printf("Copied %s of %s\n", human_bytes(atomic.LoadInt64(&progress.bytes)), name)
+	return nil
+}
+
+// progress_reader counts the bytes read from the transfer stream
+type progress_reader struct {
+	reader io.Reader
+	bytes  int64
+}
+
+func (p *progress_reader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	atomic.AddInt64(&p.bytes, int64(n))
+	return n, err
+}
+
+// Print the progress periodically until the returned function is called
+func (p *progress_reader) report(name string, total int64) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progress_interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				copied := atomic.LoadInt64(&p.bytes)
+				if total > 0 {
+					fmt.Printf("Copying %s: %s of %s (%d%%)\n", name, human_bytes(copied), human_bytes(total), min_percent(copied, total))
+				} else {
+					fmt.Printf("Copying %s: %s\n", name, human_bytes(copied))
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// tar headers make the stream slightly bigger than the files, never show more than 100%
+func min_percent(copied int64, total int64) int64 {
+	percent := copied * 100 / total
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+func human_bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+ * SPDX-License-Identifier: MIT-0
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this
+ * software and associated documentation files (the "Software"), to deal in the Software
+ * without restriction, including without limitation the rights to use, copy, modify,
+ * merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED,
+ * INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A
+ * PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT
+ * HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+ * OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package volume
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	checkpoint "containers-migration-factory/app/checkpoint"
+	cluster "containers-migration-factory/app/cluster"
+	resource "containers-migration-factory/app/resource"
+	transform "containers-migration-factory/app/transform"
+)
+
+const (
+	default_image   = "busybox:1.36"
+	mount_path      = "/data"
+	container_name  = "transfer"
+	transfer_label  = "kmf.io/transfer"
+	running_timeout = 5 * time.Minute
+	delete_timeout  = 30 * time.Second
+)
+
+// claim is a PersistentVolumeClaim of the source cluster and the claim it was migrated to
+type claim struct {
+	srcNamespace string
+	srcName      string
+	dstNamespace string
+	dstName      string
+}
+
+func (c claim) String() string {
+	return c.dstNamespace + "/" + c.dstName
+}
+
+// Copy the data of every migrated PersistentVolumeClaim from the source cluster to the destination cluster.
+// The claims must already exist in the destination cluster, a claim the run checkpoint records as data-copied is skipped
+// when the run is resumed.
+func Migrate_data(src *cluster.Cluster, dst *cluster.Cluster, resources *resource.Resources) {
+	fmt.Println("Migrating PersistentVolumeClaim data....start")
+	if src.GetRest_config() == nil {
+		fmt.Println("PersistentVolumeClaim data can only be copied from a live source cluster, skipping")
+		return
+	}
+	image := dst.GetData_image()
+	if image == "" {
+		image = default_image
+	}
+	for _, pvc := range resources.PersistentVolumeClaimsList {
+		if dst.GetCtx().Err() != nil {
+			break
+		}
+		c := claim{dstNamespace: pvc.ObjectMeta.Namespace, dstName: pvc.ObjectMeta.Name}
+		source, ok := pvc.ObjectMeta.Annotations[transform.Source_claim_annotation]
+		parts := strings.SplitN(source, "/", 2)
+		if !ok || len(parts) != 2 {
+			resources.Report.Add("data", "PersistentVolumeClaim", c.dstNamespace, c.dstName, "source claim unknown, data not copied")
+			continue
+		}
+		c.srcNamespace, c.srcName = parts[0], parts[1]
+
+		if dst.GetCheckpoint().Reached("PersistentVolumeClaim", c.dstNamespace, c.dstName, checkpoint.Data_copied) {
+			fmt.Printf("Data of %s already copied and verified, skipping\n", c)
+			continue
+		}
+		if err := migrate_claim(src, dst, image, c); err != nil {
+			fmt.Printf("Could not copy the data of %s: %v\n", c, err)
+			resources.Report.Add("data", "PersistentVolumeClaim", c.dstNamespace, c.dstName, fmt.Sprintf("data copy failed, run again to resume: %v", err))
+			continue
+		}
+		dst.GetCheckpoint().Set("PersistentVolumeClaim", c.dstNamespace, c.dstName, checkpoint.Data_copied)
+		dst.GetCheckpoint().Save()
+	}
+	fmt.Println("Migrating PersistentVolumeClaim data....End")
+}
+
+func migrate_claim(src *cluster.Cluster, dst *cluster.Cluster, image string, c claim) error {
+	source := c.srcNamespace + "/" + c.srcName
+
+	if _, err := dst.GetClientset().CoreV1().PersistentVolumeClaims(c.dstNamespace).Get(dst.GetCtx(), c.dstName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("destination claim: %v", err)
+	}
+
+	// a ReadWriteOnce volume can only be mounted on the node it is attached to
+	node, err := claim_node(src, c.srcNamespace, c.srcName)
+	if err != nil {
+		return err
+	}
+	srcPod, err := start_pod(src, image, c.srcNamespace, c.srcName, node, true)
+	if err != nil {
+		return fmt.Errorf("source transfer pod: %v", err)
+	}
+	defer delete_pod(src, srcPod)
+	dstPod, err := start_pod(dst, image, c.dstNamespace, c.dstName, "", false)
+	if err != nil {
+		return fmt.Errorf("destination transfer pod: %v", err)
+	}
+	defer delete_pod(dst, dstPod)
+
+	srcSums, err := checksums(src, srcPod)
+	if err != nil {
+		return fmt.Errorf("source checksums: %v", err)
+	}
+	dstSums, err := checksums(dst, dstPod)
+	if err != nil {
+		return fmt.Errorf("destination checksums: %v", err)
+	}
+	sizes, err := file_sizes(src, srcPod)
+	if err != nil {
+		return fmt.Errorf("source file sizes: %v", err)
+	}
+
+	// only the files missing or differing in the destination are sent, this resumes an interrupted copy
+	var files []string
+	var total int64
+	for file, sum := range srcSums {
+		if dstSums[file] != sum {
+			files = append(files, file)
+			total += sizes[file]
+		}
+	}
+	sort.Strings(files)
+
+	if len(files) > 0 {
+		fmt.Printf("Copying %d files (%s) of %s to %s\n", len(files), human_bytes(total), source, c)
+		// an empty destination gets the whole tree so directories, links and permissions are kept
+		if err := copy_files(src, srcPod, dst, dstPod, files, len(dstSums) == 0, total, c.String()); err != nil {
+			return err
+		}
+		if dstSums, err = checksums(dst, dstPod); err != nil {
+			return fmt.Errorf("destination checksums: %v", err)
+		}
+	}
+
+	var mismatches []string
+	for file, sum := range srcSums {
+		if dstSums[file] != sum {
+			mismatches = append(mismatches, file)
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("checksum mismatch for %d files, first %s", len(mismatches), mismatches[0])
+	}
+
+	fmt.Printf("Data of %s copied and verified, %d files\n", c, len(srcSums))
+	return nil
+}
+
+// Node a running pod of the source cluster mounts the claim on, empty when the claim is not in use
+func claim_node(src *cluster.Cluster, namespace string, name string) (string, error) {
+	pods, err := src.GetClientset().CoreV1().Pods(namespace).List(src.GetCtx(), metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("listing the pods of %s: %v", namespace, err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == name {
+				return pod.Spec.NodeName, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Start a pod mounting the claim under /data and wait until it runs
+func start_pod(c *cluster.Cluster, image string, namespace string, claimName string, node string, readOnly bool) (*v1.Pod, error) {
+	root := int64(0)
+	grace := int64(0)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kmf-transfer-",
+			Namespace:    namespace,
+			Labels:       map[string]string{transfer_label: "true", transform.Migrated_by_label: transform.Migrated_by_value},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy:                 v1.RestartPolicyNever,
+			NodeName:                      node,
+			TerminationGracePeriodSeconds: &grace,
+			Tolerations:                   []v1.Toleration{{Operator: v1.TolerationOpExists}},
+			Containers: []v1.Container{{
+				Name:            container_name,
+				Image:           image,
+				Command:         []string{"sleep", "86400"},
+				SecurityContext: &v1.SecurityContext{RunAsUser: &root},
+				VolumeMounts:    []v1.VolumeMount{{Name: "data", MountPath: mount_path, ReadOnly: readOnly}},
+			}},
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName, ReadOnly: readOnly},
+				},
+			}},
+		},
+	}
+
+	pods := c.GetClientset().CoreV1().Pods(namespace)
+	pod, err := pods.Create(c.GetCtx(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	err = wait.PollImmediate(2*time.Second, running_timeout, func() (bool, error) {
+		current, err := pods.Get(c.GetCtx(), pod.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch current.Status.Phase {
+		case v1.PodRunning:
+			return true, nil
+		case v1.PodFailed, v1.PodSucceeded:
+			return false, fmt.Errorf("pod %s stopped: %s", current.ObjectMeta.Name, current.Status.Message)
+		}
+		return false, nil
+	})
+	if err != nil {
+		delete_pod(c, pod)
+		return nil, fmt.Errorf("pod %s/%s did not start: %v", namespace, pod.ObjectMeta.Name, err)
+	}
+	return pod, nil
+}
+
+// The transfer pods are deleted even when the migration was interrupted
+func delete_pod(c *cluster.Cluster, pod *v1.Pod) {
+	ctx, cancel := context.WithTimeout(context.Background(), delete_timeout)
+	defer cancel()
+	grace := int64(0)
+	err := c.GetClientset().CoreV1().Pods(pod.ObjectMeta.Namespace).Delete(ctx, pod.ObjectMeta.Name, metav1.DeleteOptions{GracePeriodSeconds: &grace})
+	if err != nil {
+		fmt.Printf("Could not delete transfer pod %s/%s: %v\n", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, err)
+	}
+}
@@ -104,7 +104,7 @@ func list_ecr_repo(aws_region string) (ecr_repo_list []string) {
                         // Message from an error.
                         fmt.Println(err.Error())
                 }
-		//return err
+		return ecr_repo_list
         }
 	
 	for _, repo_list := range result.Repositories {
@@ -135,6 +135,20 @@ func STS_GetCallerIdentity() (aws_account string) {
 	return aws_account
 }
 
+// Create the ECR repository if it does not exist yet and return its URI
+func Ensure_ecr_repo(src_repo_name string, aws_region string) (ecr_repo_uri string) {
+	for _, repo_uri := range list_ecr_repo(aws_region) {
+		if strings.HasSuffix(repo_uri, "/"+src_repo_name) {
+			return repo_uri
+		}
+	}
+	ecr_repo_uri = create_ecr_repo(src_repo_name, aws_region)
+	if ecr_repo_uri != "" {
+		fmt.Println("Successfully created ECR repository named :", ecr_repo_uri)
+	}
+	return ecr_repo_uri
+}
+
 func check_ecr_repo(src_image_name string, src_repo_name string, src_image_tag string) (updated_image_name string) {
 	var validate_ecr string = ""
 	var aws_region string
@@ -5,6 +5,7 @@ go 1.16
 require (
 	github.com/aws/aws-sdk-go v1.43.16
 	github.com/bigkevmcd/go-configparser v0.0.0-20210106142102-909504547ead
+	github.com/evanphx/json-patch v5.6.0+incompatible
 	github.com/ghodss/yaml v1.0.0
 	github.com/gofrs/flock v0.8.1
 	github.com/pkg/errors v0.9.1
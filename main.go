@@ -21,6 +21,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	//AWS "containers-migration-factory/controllers/AWS"
 	//GCP "containers-migration-factory/controllers/GCP"
 	"flag"
@@ -28,20 +29,34 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 	"unicode"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"github.com/bigkevmcd/go-configparser"
 
 	gke "containers-migration-factory/app/source/gke"
 	aks "containers-migration-factory/app/source/aks"
 	kops "containers-migration-factory/app/source/kops"
+	generic "containers-migration-factory/app/source/generic"
+	velero "containers-migration-factory/app/source/velero"
+	checkpoint "containers-migration-factory/app/checkpoint"
 	cluster "containers-migration-factory/app/cluster"
+	detect "containers-migration-factory/app/detect"
+	ignore "containers-migration-factory/app/ignore"
+	application "containers-migration-factory/app/application"
+	scope "containers-migration-factory/app/scope"
 	source "containers-migration-factory/app/source"
 	resource "containers-migration-factory/app/resource"
 	eks "containers-migration-factory/app/target/eks"
 	target "containers-migration-factory/app/target"
+	transform "containers-migration-factory/app/transform"
+	volume "containers-migration-factory/app/volume"
 )
 
 type Config struct {
@@ -61,9 +76,39 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 
 	
 	namespaces_param := ""
+	namespace_mapping_param := ""
 	resources_param := ""
+	label_selector_param := ""
+	application_param := ""
+	label_selectors_param := ""
+	field_selectors_param := ""
+	include_names_param := ""
+	exclude_names_param := ""
 	helm_path_param := ""
+	helm_driver_param := ""
+	helm_package_param := ""
+	helm_registry_param := ""
+	helm_plain_http_param := ""
 	action_param := ""
+	export_path_param := ""
+	call_timeout_param := ""
+	migration_timeout_param := ""
+	resume_param := ""
+	assume_yes_param := ""
+	protected_namespaces_param := ""
+	delete_propagation_param := ""
+	delete_wait_param := ""
+	velero_backup_param := ""
+	distribution_param := ""
+	ignore_file_param := ""
+	source_workers_param := ""
+	source_page_size_param := ""
+	source_qps_param := ""
+	source_burst_param := ""
+	destination_workers_param := ""
+	destination_qps_param := ""
+	destination_burst_param := ""
+	source_type_check_param := ""
 	source_kubeconfig_param := ""
 	source_context_param := ""
 	src_cloud := ""
@@ -71,6 +116,16 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 	destination_context_param := ""
 	migrate_images_param := ""
 	reg_names_param := ""
+	migrate_data_param := ""
+	data_image_param := ""
+	source_cluster_name_param := ""
+	run_id_param := ""
+	labels_param := ""
+	annotations_param := ""
+	name_prefix_param := ""
+	name_suffix_param := ""
+	rules_file_param := ""
+	storage_class_mapping_param := ""
 
 	if fileExists("config.ini"){
 		configParams, err := configparser.NewConfigParserFromFile("config.ini")
@@ -81,9 +136,25 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 			common_options, err := configParams.Items("COMMON")
 			if err == nil{
 				namespaces_param = common_options["NAMESPACES"]
+				namespace_mapping_param = common_options["NAMESPACE_MAPPING"]
 				resources_param = common_options["RESOURCES"]
+				label_selector_param = common_options["LABEL_SELECTOR"]
+				application_param = common_options["APPLICATION"]
+				label_selectors_param = common_options["LABEL_SELECTORS"]
+				field_selectors_param = common_options["FIELD_SELECTORS"]
+				include_names_param = common_options["INCLUDE_NAMES"]
+				exclude_names_param = common_options["EXCLUDE_NAMES"]
 				helm_path_param = common_options["HELM_CHARTS_PATH"]
+				helm_driver_param = common_options["HELM_DRIVER"]
+				helm_package_param = common_options["HELM_PACKAGE_CHARTS"]
+				helm_registry_param = common_options["HELM_OCI_REGISTRY"]
+				helm_plain_http_param = common_options["HELM_OCI_PLAIN_HTTP"]
 				action_param = common_options["ACTION"]
+				export_path_param = common_options["EXPORT_PATH"]
+				call_timeout_param = common_options["CALL_TIMEOUT"]
+				migration_timeout_param = common_options["MIGRATION_TIMEOUT"]
+				resume_param = common_options["RESUME"]
+				assume_yes_param = common_options["ASSUME_YES"]
 			}
 			
 			// get source section
@@ -92,6 +163,14 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 				source_kubeconfig_param = source_options["KUBE_CONFIG"]
 				source_context_param = source_options["CONTEXT"]
 				src_cloud = source_options["CLOUD"]
+				velero_backup_param = source_options["VELERO_BACKUP"]
+				distribution_param = source_options["DISTRIBUTION"]
+				ignore_file_param = source_options["IGNORE_FILE"]
+				source_workers_param = source_options["SCAN_WORKERS"]
+				source_page_size_param = source_options["PAGE_SIZE"]
+				source_qps_param = source_options["QPS"]
+				source_burst_param = source_options["BURST"]
+				source_type_check_param = source_options["SOURCE_TYPE_CHECK"]
 			}
 
 			// get target section
@@ -99,6 +178,12 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 			if err == nil{
 				destination_kubeconfig_param = target_options["KUBE_CONFIG"]
 				destination_context_param = target_options["CONTEXT"]
+				destination_workers_param = target_options["DEPLOY_WORKERS"]
+				destination_qps_param = target_options["QPS"]
+				destination_burst_param = target_options["BURST"]
+				protected_namespaces_param = target_options["PROTECTED_NAMESPACES"]
+				delete_propagation_param = target_options["DELETE_PROPAGATION"]
+				delete_wait_param = target_options["DELETE_WAIT"]
 				// target_cloud := target_options["CLOUD"]
 			}
 
@@ -109,6 +194,26 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 					reg_names_param = migrate_image_options["REGISTRY"]
 			}
 
+			// get data migration section
+			migrate_data_options, err := configParams.Items("MIGRATE_DATA")
+			if err == nil{
+				migrate_data_param = migrate_data_options["USERCONSENT"]
+				data_image_param = migrate_data_options["IMAGE"]
+			}
+
+			// get transform section
+			transform_options, err := configParams.Items("TRANSFORM")
+			if err == nil{
+				source_cluster_name_param = transform_options["SOURCE_CLUSTER_NAME"]
+				run_id_param = transform_options["RUN_ID"]
+				labels_param = transform_options["LABELS"]
+				annotations_param = transform_options["ANNOTATIONS"]
+				name_prefix_param = transform_options["NAME_PREFIX"]
+				name_suffix_param = transform_options["NAME_SUFFIX"]
+				rules_file_param = transform_options["RULES_FILE"]
+				storage_class_mapping_param = transform_options["STORAGE_CLASS_MAPPING"]
+			}
+
 		}
 	} else{
 		fmt.Printf("Config.ini file doesn't exist and will use the user arguments\n")
@@ -116,32 +221,177 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 	//// Accept Source Cluster input
 	source_kubeconfig := flag.String("source_kubeconfig", source_kubeconfig_param, "a string")
 	namespaces := flag.String("namespaces", namespaces_param, "a string")
+	namespace_mapping := flag.String("namespace_mapping", namespace_mapping_param, "Comma separated list of source:destination namespace names, for example team-a:prod-team-a")
 	destination_kubeconfig := flag.String("destination_kubeconfig", destination_kubeconfig_param, "a string")
 	source_context := flag.String("source_context", source_context_param, "a string")
 	destination_context := flag.String("destination_context", destination_context_param, "a string")
 	resources := flag.String("resources", resources_param, "a string")
+	application_roots := flag.String("application", application_param, "Comma separated list of Kind/namespace/name workloads, HelmRelease/namespace/release or part-of=value whose closure is migrated")
+	label_selector := flag.String("label_selector", label_selector_param, "Label selector applied to every kind but Namespace when reading the source cluster, for example app.kubernetes.io/part-of=shop")
+	label_selectors := flag.String("label_selectors", label_selectors_param, "Semicolon separated list of Kind:selector label selectors, for example Deployment:tier=web;ConfigMap:app=web")
+	field_selectors := flag.String("field_selectors", field_selectors_param, "Semicolon separated list of Kind:selector field selectors, for example Secret:type!=kubernetes.io/service-account-token")
+	include_names := flag.String("include_names", include_names_param, "Comma separated list of name globs, optionally written Kind/glob, objects must match to be migrated")
+	exclude_names := flag.String("exclude_names", exclude_names_param, "Comma separated list of name globs, optionally written Kind/glob, of objects that are not migrated")
 	helm_path := flag.String("helm_path", helm_path_param, "Path on local system where Helm charts from source cluster will be stored")
+	helm_package := flag.String("helm_package", helm_package_param, "Package the extracted Helm charts as versioned .tgz archives. Supply either Yes or No")
+	helm_registry := flag.String("helm_registry", helm_registry_param, "OCI registry the packaged Helm charts are pushed to, for example <account>.dkr.ecr.<region>.amazonaws.com/kmf-charts")
+	helm_plain_http := flag.String("helm_plain_http", helm_plain_http_param, "Use plain http when pushing Helm charts to the OCI registry. Supply either Yes or No")
+	helm_driver := flag.String("helm_driver", helm_driver_param, "Helm storage driver used on the source cluster. Accepted values are secret, configmap or sql. Secrets and ConfigMaps are both scanned when empty")
 	migrate_images := flag.String("migrate_images", migrate_images_param, "User consent for migrating image from 3rd party registries to ECR")
 	reg_names := flag.String("reg_names", reg_names_param, "List of 3rd party registries as comma separated items")
-	action := flag.String("action", action_param, "What action the tools needs to perform. Accepted values are Deploy or Delete")
-	sourceType := flag.String("source_type", src_cloud, "What is source type. Accepted values are GKE,AKS,KOPS")
+	migrate_data := flag.String("migrate_data", migrate_data_param, "Copy the data of the PersistentVolumeClaims to the destination cluster after deploy. Supply either Yes or No")
+	data_image := flag.String("data_image", data_image_param, "Image of the pods transferring PersistentVolumeClaim data, defaults to busybox:1.36")
+	action := flag.String("action", action_param, "What action the tools needs to perform. Accepted values are Deploy, Delete, Export or Rollback")
+	export_path := flag.String("export_path", export_path_param, "Path of the Velero backup archive written by the Export action")
+	call_timeout := flag.String("call_timeout", call_timeout_param, "Timeout of every API call to the source and destination clusters, for example 30s, defaults to 1m")
+	resume := flag.String("resume", resume_param, "Run ID of an interrupted or failed run to resume, what it completed is skipped and what failed is retried")
+	migration_timeout := flag.String("migration_timeout", migration_timeout_param, "Time the whole migration may take before it stops with a checkpoint, for example 2h, no limit when empty")
+	velero_backup := flag.String("velero_backup", velero_backup_param, "Path to the Velero backup archive read by the VELERO source type")
+	source_cluster_name := flag.String("source_cluster_name", source_cluster_name_param, "Name of the source cluster recorded on every migrated object. Defaults to the source context")
+	run_id := flag.String("run_id", run_id_param, "Identifier of this migration run recorded on every migrated object. Generated when empty")
+	labels := flag.String("labels", labels_param, "Comma separated list of key=value labels added to every migrated object")
+	annotations := flag.String("annotations", annotations_param, "Comma separated list of key=value annotations added to every migrated object")
+	name_prefix := flag.String("name_prefix", name_prefix_param, "Prefix added to the name of every migrated object")
+	name_suffix := flag.String("name_suffix", name_suffix_param, "Suffix added to the name of every migrated object")
+	rules_file := flag.String("rules_file", rules_file_param, "Path to a YAML file with transformation rules applied to the scanned objects before deploy")
+	storage_class_mapping := flag.String("storage_class_mapping", storage_class_mapping_param, "Path to a YAML file mapping source StorageClasses to EBS and EFS CSI classes, the built-in mapping is used when empty")
+	explain := flag.Bool("explain", false, "Print which transformation rules touched which objects")
+	sourceType := flag.String("source_type", src_cloud, "What is source type. Accepted values are GKE,AKS,KOPS,GENERIC,VELERO. Detected from the source cluster when empty")
+	source_type_check := flag.String("source_type_check", source_type_check_param, "What to do when the source type does not match the source cluster. Accepted values are refuse (default), warn or off")
+	ignore_file := flag.String("ignore_file", ignore_file_param, "YAML file of system components not migrated, its lists replace the built-in lists of the same name")
+	distribution := flag.String("distribution", distribution_param, "Kubernetes distribution of a GENERIC source. Accepted values are kubeadm, openshift, rancher or eks")
+	source_workers := flag.String("source_workers", source_workers_param, "Number of concurrent List calls when scanning the source cluster, defaults to 8")
+	source_page_size := flag.String("source_page_size", source_page_size_param, "Number of objects read per List call on the source cluster, defaults to 500")
+	source_qps := flag.String("source_qps", source_qps_param, "Client side queries per second towards the source API server, defaults to 50")
+	source_burst := flag.String("source_burst", source_burst_param, "Client side burst of queries towards the source API server, defaults to 100")
+	destination_workers := flag.String("destination_workers", destination_workers_param, "Number of namespaces deployed at the same time on the destination cluster, defaults to 4")
+	destination_qps := flag.String("destination_qps", destination_qps_param, "Client side queries per second towards the destination API server, defaults to 50")
+	destination_burst := flag.String("destination_burst", destination_burst_param, "Client side burst of queries towards the destination API server, defaults to 100")
+	protected_namespaces := flag.String("protected_namespaces", protected_namespaces_param, "Comma separated list of namespaces the Delete action never touches, besides default and the kube-* namespaces")
+	assume_yes := flag.Bool("yes", assume_yes_param == "Yes" || assume_yes_param == "yes", "Delete without asking for confirmation")
+	delete_propagation := flag.String("delete_propagation", delete_propagation_param, "How the Delete and Rollback actions delete the dependents of an object. Accepted values are Background (default), Foreground or Orphan")
+	delete_wait := flag.String("delete_wait", delete_wait_param, "Time the Delete action waits for the deleted objects to be gone, for example 5m, no wait when empty")
 	flag.Parse()
 
+	sourceCluster.SetWorkers ( int(parse_number("source_workers", *source_workers, 8)) )
+	sourceCluster.SetPage_size ( int(parse_number("source_page_size", *source_page_size, 500)) )
+	sourceCluster.SetQps ( float32(parse_number("source_qps", *source_qps, 50)) )
+	sourceCluster.SetBurst ( int(parse_number("source_burst", *source_burst, 100)) )
+	destCluster.SetWorkers ( int(parse_number("destination_workers", *destination_workers, 4)) )
+	destCluster.SetQps ( float32(parse_number("destination_qps", *destination_qps, 50)) )
+	destCluster.SetBurst ( int(parse_number("destination_burst", *destination_burst, 100)) )
+	sourceCluster.SetCall_timeout ( parse_duration("call_timeout", *call_timeout, time.Minute) )
+	destCluster.SetCall_timeout ( sourceCluster.GetCall_timeout() )
+	destCluster.SetMigration_timeout ( parse_duration("migration_timeout", *migration_timeout, 0) )
+	destCluster.SetDelete_wait ( parse_duration("delete_wait", *delete_wait, 0) )
+	switch *delete_propagation = strings.TrimSpace(*delete_propagation); *delete_propagation {
+	case "", "Background", "Foreground", "Orphan":
+		destCluster.SetDelete_propagation ( *delete_propagation )
+	default:
+		fmt.Println("Invalid input for parameter \"delete_propagation\", accepted values are Background, Foreground or Orphan")
+		os.Exit(1)
+	}
+
+	// ROLLBACK =================
+	// a rollback only needs the destination cluster and the ID of the run to roll back
+	if *action == "Rollback" {
+		rollback_input(reader, &destCluster, *destination_kubeconfig, *destination_context, *helm_path, *run_id)
+		return sourceCluster, destCluster, *action, ""
+	}
+
 	// SOURCE ===================
-	if *source_kubeconfig == "" {
-		fmt.Print("Please pass the location of source kubernetes cluster kubeconfig file: ")
-		*source_kubeconfig, _ = reader.ReadString('\n')
-		//source_kubeconfig = "/home/ec2-user/.kube/gke"
+	if *sourceType == "" {
+		fmt.Print("Please pass source type  (supported source types GKE,AKS,KOPS,GENERIC,VELERO, leave empty to detect it): ")
+		*sourceType, _ = reader.ReadString('\n')
+		*sourceType = strings.TrimRight(*sourceType, "\n")
 	}
 
-	// get current source context
-	current_src_context := get_current_context(strings.TrimSuffix(*source_kubeconfig, "\n"))
+	// a Velero backup replaces the live source cluster
+	current_src_context := ""
+	if *sourceType == "VELERO" {
+		if *velero_backup == "" {
+			fmt.Print("Please pass the location of the Velero backup archive: ")
+			*velero_backup, _ = reader.ReadString('\n')
+		}
+		sourceCluster.SetVelero_backup ( strings.TrimSpace(*velero_backup) )
+		if !fileExists(sourceCluster.GetVelero_backup()) {
+			fmt.Println("Velero backup", sourceCluster.GetVelero_backup(), "not found, exiting")
+			os.Exit(4)
+		}
+		current_src_context = strings.TrimSuffix(filepath.Base(sourceCluster.GetVelero_backup()), ".tar.gz")
+	} else {
+		if *source_kubeconfig == "" {
+			fmt.Print("Please pass the location of source kubernetes cluster kubeconfig file: ")
+			*source_kubeconfig, _ = reader.ReadString('\n')
+			//source_kubeconfig = "/home/ec2-user/.kube/gke"
+		}
+
+		// get current source context
+		current_src_context = get_current_context(strings.TrimSuffix(*source_kubeconfig, "\n"))
 
-	if *source_context == "" {
-		fmt.Printf("Please pass the source context (default: %v): ", current_src_context)
-		*source_context, _ = reader.ReadString('\n')
+		if *source_context == "" {
+			fmt.Printf("Please pass the source context (default: %v): ", current_src_context)
+			*source_context, _ = reader.ReadString('\n')
+		}
+		sourceCluster.SetContext( strings.TrimSuffix(*source_context, "\n") )
+		sourceCluster.SetKubeconfig_path ( strings.TrimSuffix(*source_kubeconfig, "\n") )
+
+		*source_type_check = strings.ToLower(stripSpaces(*source_type_check))
+		if *source_type_check == "" {
+			*source_type_check = "refuse"
+		}
+		if *source_type_check != "refuse" && *source_type_check != "warn" && *source_type_check != "off" {
+			fmt.Println("Invalid source type check", *source_type_check, ", accepted values are refuse, warn or off")
+			os.Exit(4)
+		}
+
+		if *sourceType == "" || *source_type_check != "off" {
+			sourceCluster.Generate_cluster_client()
+			detected, err := detect.Detect(&sourceCluster)
+			if err != nil && *sourceType == "" {
+				fmt.Printf("Could not detect the source type: %v\n", err)
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Printf("Warning: could not check the source type %s against the source cluster: %v\n", *sourceType, err)
+			} else if *sourceType == "" {
+				if detected.Unknown {
+					fmt.Printf("Could not identify the platform of the source cluster, assuming %v: %s\n", detected, detected.Reason)
+				} else {
+					fmt.Printf("Detected source type %v: %s\n", detected, detected.Reason)
+				}
+				*sourceType = detected.Source_type
+			} else if mismatch, conclusive := detect.Check(*sourceType, detected); mismatch != "" {
+				if *source_type_check == "refuse" && conclusive {
+					fmt.Println("Error:", mismatch)
+					fmt.Println("Fix CLOUD or the source kubeconfig, or pass --source_type_check warn to migrate anyway")
+					os.Exit(4)
+				}
+				fmt.Println("Warning:", mismatch)
+			}
+			if err == nil && *distribution == "" && *sourceType == "GENERIC" {
+				*distribution = detected.Distribution
+			}
+		}
+	}
+
+	if *sourceType == "GENERIC" {
+		*distribution = strings.ToLower(stripSpaces(*distribution))
+		if *distribution != "" && *distribution != detect.Kubeadm && *distribution != detect.OpenShift && *distribution != detect.Rancher && *distribution != detect.EKS {
+			fmt.Println("Invalid distribution", *distribution, ", accepted values are kubeadm, openshift, rancher or eks")
+			os.Exit(4)
+		}
+		sourceCluster.SetDistribution ( *distribution )
+	}
+
+	system_components, err := ignore.Load(strings.TrimSpace(*ignore_file))
+	if err != nil {
+		fmt.Printf("Could not read the system components file %s: %v\n", *ignore_file, err)
+		os.Exit(4)
 	}
-	sourceCluster.SetContext( strings.TrimSuffix(*source_context, "\n") )
+	sourceCluster.SetIgnore ( system_components.For(*sourceType, sourceCluster.GetDistribution()) )
+	// the cluster scoped objects the EKS platform brings itself are not deployed over it
+	destCluster.SetIgnore ( system_components.For("", detect.EKS) )
 
 	if *resources == "" {
 		fmt.Printf("Please pass comma separated list of resources to migrate from source cluster to destination cluster. For all resources enter 'all': ")
@@ -160,6 +410,14 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 
 	sourceCluster.SetHelm_path ( strings.TrimSuffix(*helm_path, "\n") )
 	destCluster.SetHelm_path ( strings.TrimSuffix(*helm_path, "\n") )
+	sourceCluster.SetHelm_driver ( stripSpaces(*helm_driver) )
+	sourceCluster.SetHelm_package ( stripSpaces(*helm_package) )
+	sourceCluster.SetHelm_registry ( stripSpaces(*helm_registry) )
+	sourceCluster.SetHelm_plain_http ( stripSpaces(*helm_plain_http) )
+	if sourceCluster.GetHelm_registry() != "" {
+		// charts have to be packaged before they can be pushed
+		sourceCluster.SetHelm_package ( "Yes" )
+	}
 
 	// Remove the newline character from the end of filepath entered by user
 	sourceCluster.SetKubeconfig_path ( strings.TrimSuffix(*source_kubeconfig, "\n") )
@@ -212,50 +470,154 @@ func Get_user_input(reader *bufio.Reader) (cluster.Cluster, cluster.Cluster, str
 		sourceCluster.SetNamespaces ( strings.Split(stripSpaces(*namespaces), ",") )
 	}
 
-	*resources = strings.TrimSuffix(*resources, "\n")
-	if *resources != "" {
-		sourceCluster.SetResources ( strings.Split(stripSpaces(*resources), ",") )
-		destCluster.SetResources ( sourceCluster.GetResources() )
+	if *namespace_mapping != "" {
+		destCluster.SetNamespace_mapping ( parse_namespace_mapping(*namespace_mapping) )
 	}
 
-	// DESTINATION ==============
-	if *destination_kubeconfig == "" {
-		fmt.Print("Please pass the location of destination EKS cluster kubeconfig file: ")
-		*destination_kubeconfig, _ = reader.ReadString('\n')
+	source_scope, err := scope.Parse(*label_selector, *label_selectors, *field_selectors, *include_names, *exclude_names)
+	if err != nil {
+		fmt.Println("Invalid scope:", err)
+		os.Exit(4)
 	}
+	sourceCluster.SetScope ( source_scope )
 
-	// get current destination context
-	current_dst_context := get_current_context(strings.TrimSuffix(*destination_kubeconfig, "\n"))
+	roots, err := application.Parse(*application_roots)
+	if err != nil {
+		fmt.Println("Invalid application:", err)
+		os.Exit(4)
+	}
+	sourceCluster.SetApplication ( roots )
 
-	if *destination_context == "" {
-		fmt.Printf("Please pass the destination context (default: %v): ", current_dst_context)
-		*destination_context, _ = reader.ReadString('\n')
+	*resources = strings.TrimSuffix(*resources, "\n")
+	if *resources != "" {
+		sourceCluster.SetResources ( strings.Split(stripSpaces(*resources), ",") )
+		destCluster.SetResources ( sourceCluster.GetResources() )
 	}
 
 	// Action for the tool
-	if *action != "Deploy" && *action != "Delete" {
-		fmt.Print("Please pass what action the tool needs to perform. Accepted values are Deploy or Delete : ")
+	if *action != "Deploy" && *action != "Delete" && *action != "Export" {
+		fmt.Print("Please pass what action the tool needs to perform. Accepted values are Deploy, Delete or Export : ")
 		*action, _ = reader.ReadString('\n')
 		*action = strings.TrimSuffix(*action, "\n")
 		//fmt.Println("action entered", *action)
-		if *action != "Deploy" && *action != "Delete" {
-			fmt.Print("Invalid input for parameter \"action\", accepted values are Deploy or Delete")
+		if *action != "Deploy" && *action != "Delete" && *action != "Export" {
+			fmt.Print("Invalid input for parameter \"action\", accepted values are Deploy, Delete or Export")
 			os.Exit(1)
 		}
 	}
 
+	// DESTINATION ==============
+	// Export writes an archive and does not need a destination cluster
+	if *action != "Export" {
+		if *destination_kubeconfig == "" {
+			fmt.Print("Please pass the location of destination EKS cluster kubeconfig file: ")
+			*destination_kubeconfig, _ = reader.ReadString('\n')
+		}
+
+		// get current destination context
+		current_dst_context := get_current_context(strings.TrimSuffix(*destination_kubeconfig, "\n"))
+
+		if *destination_context == "" {
+			fmt.Printf("Please pass the destination context (default: %v): ", current_dst_context)
+			*destination_context, _ = reader.ReadString('\n')
+		}
+	}
+
 	// fmt.Println("Action", *action)
 
 	
 	
-	if *sourceType == "" {
-		fmt.Print("Please pass source type  (supported source types GKE,AKS,KOPS): ")
-		*sourceType, _ = reader.ReadString('\n')
-		*sourceType = strings.TrimRight(*sourceType, "\n")
-	}
 
 	destCluster.SetKubeconfig_path ( strings.TrimSuffix(*destination_kubeconfig, "\n") )
 	destCluster.SetContext ( strings.TrimSuffix(*destination_context, "\n") )
+	destCluster.SetMigrate_data ( stripSpaces(*migrate_data) )
+	destCluster.SetData_image ( stripSpaces(*data_image) )
+	if *protected_namespaces = stripSpaces(*protected_namespaces); *protected_namespaces != "" {
+		destCluster.SetProtected_namespaces ( strings.Split(*protected_namespaces, ",") )
+	}
+	destCluster.SetAssume_yes ( *assume_yes )
+
+	// TRANSFORM ================
+	*resume = strings.TrimSpace(*resume)
+	if *resume != "" {
+		if *run_id != "" && *run_id != *resume {
+			fmt.Println("The run ID", *run_id, "differs from the run", *resume, "to resume, leave it empty")
+			os.Exit(4)
+		}
+		*run_id = *resume
+	}
+	if *run_id == "" {
+		*run_id = time.Now().UTC().Format("20060102-150405")
+	}
+	if errs := validation.IsValidLabelValue(*run_id); len(errs) > 0 {
+		fmt.Println("Invalid run id", *run_id, ":", strings.Join(errs, ", "))
+		os.Exit(4)
+	}
+	fmt.Println("Migration run ID:", *run_id)
+	destCluster.SetRun_id ( *run_id )
+
+	// the checkpoint of the run is saved as it goes, it is resumed with --resume <run id>
+	checkpoint_path := filepath.Join(destCluster.GetHelm_path(), "KMFCheckpoint", *run_id+".json")
+	run_checkpoint := checkpoint.New(checkpoint_path, *run_id, *action)
+	if *resume != "" {
+		var err error
+		if run_checkpoint, err = checkpoint.Load(checkpoint_path); err != nil {
+			fmt.Printf("Cannot resume run %s: %v\n", *resume, err)
+			os.Exit(4)
+		}
+		if run_checkpoint.Action != *action {
+			fmt.Println("Run", *resume, "was a", run_checkpoint.Action, "and cannot be resumed as a", *action)
+			os.Exit(4)
+		}
+		counts := run_checkpoint.Count()
+		copied := 0
+		for _, image := range run_checkpoint.Images {
+			if image != "" {
+				copied++
+			}
+		}
+		fmt.Printf("Resuming run %s stopped in stage %s: %d objects created, %d failed and retried, %d images copied\n", *resume, run_checkpoint.Stage,
+			counts[checkpoint.Created]+counts[checkpoint.Verified]+counts[checkpoint.Data_copied], counts[checkpoint.Failed], copied)
+	}
+	sourceCluster.SetCheckpoint ( run_checkpoint )
+	destCluster.SetCheckpoint ( run_checkpoint )
+
+	if *action == "Export" {
+		if strings.TrimSpace(*export_path) == "" {
+			*export_path = filepath.Join(destCluster.GetHelm_path(), "KMFExport", *run_id+".tar.gz")
+		}
+		destCluster.SetExport_path ( strings.TrimSpace(*export_path) )
+	}
+
+	if *source_cluster_name == "" {
+		*source_cluster_name = sourceCluster.GetContext()
+	}
+	if *source_cluster_name == "" {
+		*source_cluster_name = current_src_context
+	}
+
+	migration_labels := parse_key_values(*labels, true)
+	migration_labels[transform.Migrated_by_label] = transform.Migrated_by_value
+	migration_labels[transform.Run_id_label] = *run_id
+	destCluster.SetLabels ( migration_labels )
+
+	migration_annotations := parse_key_values(*annotations, false)
+	migration_annotations[transform.Source_cluster_annotation] = *source_cluster_name
+	destCluster.SetAnnotations ( migration_annotations )
+
+	destCluster.SetName_prefix ( stripSpaces(*name_prefix) )
+	destCluster.SetName_suffix ( stripSpaces(*name_suffix) )
+	// a mistake in the rules file stops the run before the source cluster is scanned
+	if rules_path := strings.TrimSpace(*rules_file); rules_path != "" {
+		rules, err := transform.Load_rules(rules_path)
+		if err != nil {
+			fmt.Printf("Could not load transformation rules from %s: %v\n", rules_path, err)
+			os.Exit(4)
+		}
+		destCluster.SetRules ( rules )
+	}
+	destCluster.SetExplain ( *explain )
+	destCluster.SetStorage_class_mapping ( strings.TrimSpace(*storage_class_mapping) )
 
 	return sourceCluster, destCluster , *action, *sourceType
 }
@@ -280,6 +642,112 @@ func get_current_context(kubeconfigPath string) (default_context string) {
 	return default_context
 }
 
+// Positive number given in config.ini or on the command line, def when empty
+func parse_number(name string, value string, def float64) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return def
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		fmt.Println("Invalid", name, value, ", a positive number is expected")
+		os.Exit(4)
+	}
+	return number
+}
+
+// Ask for the destination cluster of the Rollback action and load the checkpoint of the run to roll back, the run ID
+// is asked for when not given
+func rollback_input(reader *bufio.Reader, destCluster *cluster.Cluster, destination_kubeconfig string, destination_context string, helm_path string, run_id string) {
+	if strings.TrimSpace(run_id) == "" {
+		fmt.Print("Please pass the ID of the run to roll back: ")
+		run_id, _ = reader.ReadString('\n')
+	}
+	run_id = strings.TrimSpace(run_id)
+	if errs := validation.IsValidLabelValue(run_id); run_id == "" || len(errs) > 0 {
+		fmt.Println("Invalid run id", run_id, ":", strings.Join(errs, ", "))
+		os.Exit(4)
+	}
+
+	if destination_kubeconfig == "" {
+		fmt.Print("Please pass the location of destination EKS cluster kubeconfig file: ")
+		destination_kubeconfig, _ = reader.ReadString('\n')
+	}
+	current_dst_context := get_current_context(strings.TrimSuffix(destination_kubeconfig, "\n"))
+	if destination_context == "" {
+		fmt.Printf("Please pass the destination context (default: %v): ", current_dst_context)
+		destination_context, _ = reader.ReadString('\n')
+	}
+	if helm_path == "" {
+		fmt.Print("Please pass the path the Helm charts and checkpoints of the run were saved to: ")
+		helm_path, _ = reader.ReadString('\n')
+	}
+
+	destCluster.SetKubeconfig_path ( strings.TrimSuffix(destination_kubeconfig, "\n") )
+	destCluster.SetContext ( strings.TrimSuffix(destination_context, "\n") )
+	destCluster.SetHelm_path ( strings.TrimSuffix(helm_path, "\n") )
+	destCluster.SetRun_id ( run_id )
+
+	// without the checkpoint of the run only the objects carrying its label are found
+	checkpoint_path := filepath.Join(destCluster.GetHelm_path(), "KMFCheckpoint", run_id+".json")
+	run_checkpoint, err := checkpoint.Load(checkpoint_path)
+	if err != nil {
+		fmt.Printf("Warning: no checkpoint of run %s could be read (%v), only the objects labelled with its run ID are deleted\n", run_id, err)
+		run_checkpoint = checkpoint.New(checkpoint_path, run_id, "Rollback")
+	}
+	destCluster.SetCheckpoint ( run_checkpoint )
+	fmt.Println("Migration run ID to roll back:", run_id)
+}
+
+// parse a duration option such as 30s or 2h, def when empty
+func parse_duration(name string, value string, def time.Duration) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return def
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		fmt.Println("Invalid", name, value, ", a positive duration such as 30s or 2h is expected")
+		os.Exit(4)
+	}
+	return duration
+}
+
+// Parse a comma separated list of source:destination namespace names
+func parse_namespace_mapping(mapping string) map[string]string {
+	namespace_mapping := make(map[string]string)
+	for _, item := range strings.Split(stripSpaces(mapping), ",") {
+		if item == "" {
+			continue
+		}
+		names := strings.Split(item, ":")
+		if len(names) != 2 || len(validation.IsDNS1123Label(names[0])) > 0 || len(validation.IsDNS1123Label(names[1])) > 0 {
+			fmt.Println("Invalid namespace mapping", item, "expected source:destination, exiting")
+			os.Exit(4)
+		}
+		namespace_mapping[names[0]] = names[1]
+	}
+	return namespace_mapping
+}
+
+// Parse a comma separated list of key=value pairs, values are validated as label values when labels is set
+func parse_key_values(pairs string, labels bool) map[string]string {
+	key_values := make(map[string]string)
+	for _, item := range strings.Split(pairs, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || len(validation.IsQualifiedName(kv[0])) > 0 || (labels && len(validation.IsValidLabelValue(kv[1])) > 0) {
+			fmt.Println("Invalid key=value pair", item, ", exiting")
+			os.Exit(4)
+		}
+		key_values[kv[0]] = kv[1]
+	}
+	return key_values
+}
+
 // helper function to drop spaces
 func stripSpaces(str string) string {
 	return strings.Map(func(r rune) rune {
@@ -299,32 +767,84 @@ func main() {
 	sourceCluster, destCluster, action, sourceType := Get_user_input(reader)
 	fmt.Println(action)
 
+	// Ctrl-C or SIGTERM stop the migration with a checkpoint, a second one kills it right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	if timeout := destCluster.GetMigration_timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	/*Get connect with source and target clusters*/
 
 	//fmt.Println("sourceType:::", *sourceType)
 	g := new(gke.GKE)
 	a := new(aks.AKS)
 	k := new(kops.KOPS)
+	gen := new(generic.GENERIC)
+	vb := new(velero.VELERO)
 	t := new(eks.EKS)
 	var sourceResources resource.Resources
-	target.SetContext(t,&destCluster)
+	if action != "Export" {
+		target.SetContext(t,&destCluster)
+	}
 
-	if sourceType == "GKE"  {
+	if action == "Rollback" {
+		target.Invoke(ctx, t, sourceType, &sourceCluster, &destCluster, &sourceResources, action)
+	} else if sourceType == "GKE"  {
 		fmt.Println("GKE Resources")
 		source.SetContext(g,&sourceCluster)
-		sourceResources = source.Invoke( g , sourceType, &sourceCluster, &destCluster)
+		sourceResources = source.Invoke(ctx, g, sourceType, &sourceCluster, &destCluster)
 		// fmt.Println(sourceResources)
 	} else if sourceType == "AKS" {
 		source.SetContext(a,&sourceCluster)
-		sourceResources = source.Invoke(a , sourceType, &sourceCluster, &destCluster )
+		sourceResources = source.Invoke(ctx, a, sourceType, &sourceCluster, &destCluster )
 		// fmt.Println(sourceResources)
 	} else if sourceType == "KOPS" {
 		source.SetContext(k,&sourceCluster)
-		sourceResources = source.Invoke(k, sourceType, &sourceCluster, &destCluster )
+		sourceResources = source.Invoke(ctx, k, sourceType, &sourceCluster, &destCluster )
 		// fmt.Println(sourceResources)
+	} else if sourceType == "GENERIC" {
+		source.SetContext(gen,&sourceCluster)
+		sourceResources = source.Invoke(ctx, gen, sourceType, &sourceCluster, &destCluster )
+	} else if sourceType == "VELERO" {
+		source.SetContext(vb,&sourceCluster)
+		sourceResources = source.Invoke(ctx, vb, sourceType, &sourceCluster, &destCluster )
 	} else{
-		fmt.Println("Invalid input for parameter \"sourceType\", accepted values are GKE,AKE,KOPS")
+		fmt.Println("Invalid input for parameter \"sourceType\", accepted values are GKE,AKE,KOPS,GENERIC,VELERO")
 		os.Exit(1)
 	}
-	target.Invoke(t,sourceType, &sourceCluster, &destCluster,&sourceResources, action)
+
+	if action == "Export" {
+		if err := velero.Export(&sourceResources, destCluster.GetExport_path()); err != nil {
+			fmt.Printf("Could not export the resources to %s: %v\n", destCluster.GetExport_path(), err)
+			os.Exit(1)
+		}
+	} else if action != "Rollback" {
+		target.Invoke(ctx, t,sourceType, &sourceCluster, &destCluster,&sourceResources, action)
+	}
+
+	if action == "Deploy" && (destCluster.GetMigrate_data() == "Yes" || destCluster.GetMigrate_data() == "yes") {
+		destCluster.GetCheckpoint().Start("data")
+		volume.Migrate_data(&sourceCluster, &destCluster, &sourceResources)
+		destCluster.Exit_if_interrupted()
+	}
+
+	if sourceResources.Report != nil {
+		sourceResources.Report.Print()
+	}
+
+	if failed := destCluster.GetCheckpoint().Failures(); len(failed) > 0 {
+		fmt.Printf("%d objects failed, they are listed in %s\n", len(failed), destCluster.GetCheckpoint().Path())
+		if action == "Rollback" {
+			fmt.Println("Run the rollback again to retry them")
+		} else {
+			fmt.Println("Run again with --resume", destCluster.GetRun_id(), "to retry them")
+		}
+	}
 }